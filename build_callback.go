@@ -0,0 +1,86 @@
+package restspec
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/emicklei/go-restful/v3"
+	spec "github.com/getkin/kin-openapi/openapi3"
+)
+
+// addCallbacks adds the callbacks declared with Callbacks to the operation.
+func addCallbacks(o *spec.Operation, r restful.Route, cfg Config, names *componentNames) {
+	for key, value := range r.Metadata {
+		callback, ok := value.(Callback)
+		if !ok || !strings.HasPrefix(key, KeyOpenAPICallback+".") {
+			continue
+		}
+		if o.Callbacks == nil {
+			o.Callbacks = spec.Callbacks{}
+		}
+		pathItem := &spec.PathItem{}
+		method := callback.Method
+		if method == "" {
+			method = http.MethodPost
+		}
+		pathItem.SetOperation(method, buildCallbackOperation(callback, cfg, names))
+		o.Callbacks[strings.TrimPrefix(key, KeyOpenAPICallback+".")] = &spec.CallbackRef{
+			Value: spec.NewCallback(spec.WithCallback(callback.Expression, pathItem)),
+		}
+	}
+}
+
+func buildCallbackOperation(callback Callback, cfg Config, names *componentNames) *spec.Operation {
+	o := spec.NewOperation()
+	o.Summary = callback.Doc
+	if callback.Reads != nil {
+		mediaType := callback.MediaType
+		if mediaType == "" {
+			mediaType = restful.MIME_JSON
+		}
+		o.RequestBody = &spec.RequestBodyRef{
+			Value: spec.NewRequestBody().WithRequired(true).WithContent(spec.Content{
+				mediaType: spec.NewMediaType().WithSchemaRef(buildModelSchema(callback.Reads, cfg, names)),
+			}),
+		}
+	}
+	o.Responses = new(spec.Responses)
+	for _, e := range callback.Returns {
+		rsp := buildResponse(e, cfg, names, []string{restful.MIME_JSON})
+		// like ReturnsStream, a code of 0 or less is the default response
+		code := e.Code
+		if e.IsDefault {
+			code = -1
+		}
+		o.Responses.Set(statusKey(code), &spec.ResponseRef{Value: &rsp})
+	}
+	if o.Responses.Len() == 0 {
+		o.AddResponse(200, (&spec.Response{}).WithDescription(http.StatusText(http.StatusOK)))
+	}
+	return o
+}
+
+// addResponseLinks adds the links declared with ResponseLink to the responses of the operation.
+func addResponseLinks(o *spec.Operation, r restful.Route) {
+	for key, value := range r.Metadata {
+		link, ok := value.(Link)
+		if !ok || !strings.HasPrefix(key, KeyOpenAPILink+".") {
+			continue
+		}
+		code, name, _ := strings.Cut(strings.TrimPrefix(key, KeyOpenAPILink+"."), ".")
+		rsp := responseOf(o, code)
+		if rsp == nil {
+			continue
+		}
+		if rsp.Value.Links == nil {
+			rsp.Value.Links = spec.Links{}
+		}
+		rsp.Value.Links[name] = &spec.LinkRef{
+			Value: &spec.Link{
+				OperationID: link.OperationID,
+				Parameters:  link.Parameters,
+				Description: link.Description,
+			},
+		}
+	}
+}
@@ -7,26 +7,72 @@ import (
 	spec "github.com/getkin/kin-openapi/openapi3"
 )
 
-func buildSchemas(ws *restful.WebService, cfg Config) (schemas spec.Schemas) {
+func buildSchemas(ws *restful.WebService, cfg Config, names *componentNames) (schemas spec.Schemas) {
 	schemas = spec.Schemas{}
 	for _, each := range ws.Routes() {
-		addSchemaFromRouteTo(each, cfg, &schemas)
+		addSchemaFromRouteTo(each, cfg, names, &schemas)
 	}
 	return
 }
 
-func addSchemaFromRouteTo(r restful.Route, cfg Config, s *spec.Schemas) {
-	builder := schemaBuilder{Schemas: s, Config: cfg}
+func addSchemaFromRouteTo(r restful.Route, cfg Config, names *componentNames, s *spec.Schemas) {
+	builder := schemaBuilder{Schemas: s, Config: cfg, Names: names}
 	if r.ReadSample != nil {
 		builder.addModel(reflect.TypeOf(r.ReadSample), "")
 	}
-	if r.WriteSample != nil {
+	if _, ok := downloadOf(r.WriteSample); !ok && r.WriteSample != nil {
 		builder.addModel(reflect.TypeOf(r.WriteSample), "")
 	}
+	for _, param := range r.ParameterDocs {
+		if mt, ok := param.Data().Extensions[keyParamModel].(reflect.Type); ok {
+			builder.addModel(mt, "")
+		}
+		if et, ok := param.Data().Extensions[keyParamType].(reflect.Type); ok {
+			builder.enumRef(et)
+		}
+	}
+	for _, v := range r.Metadata {
+		if callback, ok := v.(Callback); ok {
+			if callback.Reads != nil {
+				builder.addModel(reflect.TypeOf(callback.Reads), "")
+			}
+			for _, e := range callback.Returns {
+				if e.Model != nil {
+					builder.addModel(reflect.TypeOf(e.Model), "")
+				}
+			}
+		}
+		if stream, ok := v.(Stream); ok {
+			if stream.Item != nil {
+				builder.addModel(reflect.TypeOf(stream.Item), "")
+			}
+			for _, event := range stream.Events {
+				if event.Data != nil {
+					builder.addModel(reflect.TypeOf(event.Data), "")
+				}
+			}
+		}
+	}
 	for _, v := range r.ResponseErrors {
-		if v.Model == nil {
+		if _, ok := downloadOf(v.Model); ok || v.Model == nil {
 			continue
 		}
 		builder.addModel(reflect.TypeOf(v.Model), "")
 	}
 }
+
+func buildSecuritySchemes(ws *restful.WebService) (schemes spec.SecuritySchemes) {
+	schemes = spec.SecuritySchemes{}
+	for _, each := range ws.Routes() {
+		if cookie, ok := each.Metadata[KeySecurityCookie].(string); ok {
+			schemes[cookie] = &spec.SecuritySchemeRef{
+				Value: &spec.SecurityScheme{
+					Type: "apiKey",
+					In:   "cookie",
+					Name: cookie,
+				},
+			}
+		}
+	}
+	return
+}
@@ -16,15 +16,21 @@ const (
 	// KeyOpenAPITags is a Metadata key for a restful Route
 	KeyOpenAPITags = "openapi.tags"
 
+	// KeySecurityJWT is a Metadata key for a restful Route, the value is the name of a security scheme
 	KeySecurityJWT = "security.jwt"
+	// KeySecurityCookie is a Metadata key for a restful Route, the value is the name of the cookie
+	// holding an API key. It also produces an apiKey security scheme with the name of the cookie.
+	KeySecurityCookie = "security.cookie"
 
 	// ExtensionPrefix is the only prefix accepted for VendorExtensible extension keys
 	ExtensionPrefix = "x-"
 
 	arrayType     = "array"
 	componentRoot = "#/components/schemas/"
+	headerRoot    = "#/components/headers/"
 
-	MIME_FORMDATA = "multipart/form-data"
+	MIME_FORMDATA   = "multipart/form-data"
+	MIME_URLENCODED = "application/x-www-form-urlencoded"
 )
 
 // SchemaType is used to wrap any raw types
@@ -35,7 +41,7 @@ type SchemaType struct {
 	Format  string
 }
 
-func buildPaths(ws *restful.WebService, cfg Config) spec.Paths {
+func buildPaths(ws *restful.WebService, cfg Config, names *componentNames) spec.Paths {
 	p := spec.Paths{}
 	for _, each := range ws.Routes() {
 		path, patterns := sanitizePath(each.Path)
@@ -43,7 +49,7 @@ func buildPaths(ws *restful.WebService, cfg Config) spec.Paths {
 		if existingPathItem == nil {
 			existingPathItem = &spec.PathItem{}
 		}
-		pathItem := buildPathItem(ws, each, *existingPathItem, patterns, cfg)
+		pathItem := buildPathItem(ws, each, *existingPathItem, patterns, cfg, names)
 		p.Set(path, &pathItem)
 	}
 	return p
@@ -79,8 +85,8 @@ func sanitizePath(restfulPath string) (string, map[string]string) {
 	return openapiPath, patterns
 }
 
-func buildPathItem(ws *restful.WebService, r restful.Route, existingPathItem spec.PathItem, patterns map[string]string, cfg Config) spec.PathItem {
-	op := buildOperation(ws, r, patterns, cfg)
+func buildPathItem(ws *restful.WebService, r restful.Route, existingPathItem spec.PathItem, patterns map[string]string, cfg Config, names *componentNames) spec.PathItem {
+	op := buildOperation(ws, r, patterns, cfg, names)
 	switch r.Method {
 	case http.MethodGet:
 		existingPathItem.Get = op
@@ -100,12 +106,17 @@ func buildPathItem(ws *restful.WebService, r restful.Route, existingPathItem spe
 	return existingPathItem
 }
 
-func buildOperation(ws *restful.WebService, r restful.Route, patterns map[string]string, cfg Config) *spec.Operation {
+func buildOperation(ws *restful.WebService, r restful.Route, patterns map[string]string, cfg Config, names *componentNames) *spec.Operation {
 	o := spec.NewOperation()
 	o.OperationID = r.Operation
 	o.Description = r.Notes
+	if o.Description == "" && r.Function != nil {
+		// the doc comment of the function, see RegisterFuncDoc
+		o.Description = docIndex.funcs[funcName(r.Function)]
+	}
 	o.Summary = stripTags(r.Doc)
 	o.Deprecated = r.Deprecated
+	reportSampleErrors(r, cfg)
 
 	if o.Extensions == nil {
 		o.Extensions = map[string]interface{}{}
@@ -125,60 +136,149 @@ func buildOperation(ws *restful.WebService, r restful.Route, patterns map[string
 				}}
 			}
 		}
-	}
-
-	o.RequestBody = &spec.RequestBodyRef{
-		Value: &spec.RequestBody{
-			Content: map[string]*spec.MediaType{},
-		},
+		if cookie, ok := r.Metadata[KeySecurityCookie].(string); ok {
+			if o.Security == nil {
+				o.Security = spec.NewSecurityRequirements()
+			}
+			o.Security.With(spec.NewSecurityRequirement().Authenticate(cookie))
+		}
 	}
 
 	// collect any path parameters
 	for _, param := range ws.PathParameters() {
-		p := buildParameter(r, param, patterns[param.Data().Name], cfg)
+		p := buildParameter(r, param, patterns[param.Data().Name], cfg, names)
 		o.AddParameter(&p)
 	}
-	// route specific params
+	// route specific params, form parameters are folded into the request body
+	var formParams []spec.Parameter
+	encodings := map[string]*Encoding{}
 	for _, param := range r.ParameterDocs {
-		p := buildParameter(r, param, patterns[param.Data().Name], cfg)
+		p := buildParameter(r, param, patterns[param.Data().Name], cfg, names)
 		switch p.In {
 		case "body":
-			content := map[string]*spec.MediaType{}
-			for _, consume := range r.Consumes {
-				content[consume] = &spec.MediaType{
+			body := requestBodyOf(o)
+			body.Description = p.Description
+			body.Required = p.Required
+			for _, consume := range consumesOf(r, cfg) {
+				body.Content[consume] = &spec.MediaType{
 					Schema: p.Schema,
 				}
 			}
-
-			o.RequestBody.Value.Content = content
-		case "multipartFormData":
-			mt := o.RequestBody.Value.Content.Get(MIME_FORMDATA)
-			if mt == nil {
-				mt = &spec.MediaType{
-					Schema: &spec.SchemaRef{Value: spec.NewObjectSchema()},
-				}
+		case "formData", "multipartFormData":
+			formParams = append(formParams, p)
+			if encoding := encodingOf(r, param.Data()); encoding != nil {
+				encodings[p.Name] = encoding
 			}
-			mt.Schema.Value.Properties[p.Name] = p.Schema
-			o.RequestBody.Value.Content[MIME_FORMDATA] = mt
 		default:
 			o.AddParameter(&p)
 		}
 	}
+	if len(formParams) > 0 {
+		addFormParameters(o, r, formParams, encodings)
+	}
 	o.Responses = new(spec.Responses)
 	for k, v := range r.ResponseErrors {
-		rsp := buildResponse(v, cfg, r.Produces)
+		rsp := buildResponse(v, cfg, names, productsOf(r, k))
 		o.AddResponse(k, &rsp)
 	}
 	if r.DefaultResponse != nil {
-		rsp := buildResponse(*r.DefaultResponse, cfg, r.Produces)
+		rsp := buildResponse(*r.DefaultResponse, cfg, names, productsOf(r, -1))
 		o.AddResponse(-1, &rsp)
 	}
 	if o.Responses.Len() == 0 {
 		o.AddResponse(200, (&spec.Response{}).WithDescription(http.StatusText(http.StatusOK)))
 	}
+	addResponseHeaders(o, r, cfg)
+	addStreamContent(o, r, cfg, names)
+	addRangeResponses(o, r)
+	addResponseLinks(o, r)
+	addCallbacks(o, r, cfg, names)
 	return o
 }
 
+// requestBodyOf returns the request body of the operation, creating it on first use
+// so that routes without a body or form parameter do not get an empty one.
+func requestBodyOf(o *spec.Operation) *spec.RequestBody {
+	if o.RequestBody == nil {
+		o.RequestBody = &spec.RequestBodyRef{
+			Value: spec.NewRequestBody().WithContent(spec.Content{}),
+		}
+	}
+	return o.RequestBody.Value
+}
+
+// addFormParameters adds the form parameters as properties of an object schema in the request body.
+// The body is encoded as application/x-www-form-urlencoded unless one of the parameters is
+// a file or the route only consumes multipart/form-data. Encodings of multipart parts are added by name.
+func addFormParameters(o *spec.Operation, r restful.Route, params []spec.Parameter, encodings map[string]*Encoding) {
+	mime := MIME_URLENCODED
+	if hasMIME(r.Consumes, MIME_FORMDATA) && !hasMIME(r.Consumes, MIME_URLENCODED) {
+		mime = MIME_FORMDATA
+	}
+	for _, p := range params {
+		if p.In == "multipartFormData" {
+			mime = MIME_FORMDATA
+		}
+	}
+
+	body := requestBodyOf(o)
+	mt := body.Content.Get(mime)
+	if mt == nil {
+		mt = spec.NewMediaType().WithSchema(spec.NewObjectSchema())
+		body.Content[mime] = mt
+	}
+	for _, p := range params {
+		if p.Schema.Value != nil && p.Schema.Value.Description == "" {
+			p.Schema.Value.Description = p.Description
+		}
+		mt.Schema.Value.Properties[p.Name] = p.Schema
+		encoding := encodings[p.Name]
+		if p.Required || (encoding != nil && encoding.Required) {
+			mt.Schema.Value.Required = append(mt.Schema.Value.Required, p.Name)
+			body.Required = true
+		}
+		if encoding != nil && mime == MIME_FORMDATA {
+			mt.WithEncoding(p.Name, buildEncoding(*encoding))
+		}
+	}
+}
+
+// buildEncoding builds a specification encoding structure from Encoding
+func buildEncoding(encoding Encoding) *spec.Encoding {
+	e := spec.NewEncoding()
+	e.ContentType = encoding.ContentType
+	if len(encoding.Headers) > 0 {
+		e.Headers = make(spec.Headers, len(encoding.Headers))
+		for k, v := range encoding.Headers {
+			headerRef := buildHeader(v)
+			e.Headers[k] = &headerRef
+		}
+	}
+	return e
+}
+
+func hasMIME(mimes []string, mime string) bool {
+	for _, each := range mimes {
+		if each == mime {
+			return true
+		}
+	}
+	return false
+}
+
+// consumesOf returns the MIME types accepted by the route, which include those of its WebService
+// declared before the route was added. If there are none, the DefaultConsumes of the config are used
+// and application/json when the config declares none either.
+func consumesOf(r restful.Route, cfg Config) []string {
+	if len(r.Consumes) > 0 {
+		return r.Consumes
+	}
+	if len(cfg.DefaultConsumes) > 0 {
+		return cfg.DefaultConsumes
+	}
+	return []string{restful.MIME_JSON}
+}
+
 // stringAutoType picks the correct type when dataType is set. Otherwise, it automatically picks the correct type from
 // an ambiguously typed string. Ex. numbers become int, true/false become bool, etc.
 func stringAutoType(dataType, ambiguous string) interface{} {
@@ -192,6 +292,12 @@ func stringAutoType(dataType, ambiguous string) interface{} {
 		}
 	}
 
+	if dataType == "number" {
+		if parsedFloat, err := strconv.ParseFloat(ambiguous, 64); err == nil {
+			return parsedFloat
+		}
+	}
+
 	if dataType == "" || dataType == "boolean" {
 		if parsedBool, err := strconv.ParseBool(ambiguous); err == nil {
 			return parsedBool
@@ -201,6 +307,48 @@ func stringAutoType(dataType, ambiguous string) interface{} {
 	return ambiguous
 }
 
+// arrayAutoType parses the comma separated default value of an array parameter into its items.
+func arrayAutoType(dataType, ambiguous string) interface{} {
+	if ambiguous == "" {
+		return nil
+	}
+
+	values := strings.Split(ambiguous, ",")
+	items := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		items = append(items, stringAutoType(dataType, strings.TrimSpace(value)))
+	}
+	return items
+}
+
+// setEnumParamSchema refers the schema of a parameter, or of its items, to the component of its enum type.
+// Its constraints, e.g. a default value, are kept next to the reference by allOf.
+func setEnumParamSchema(schema *spec.SchemaRef, et reflect.Type, cfg Config, names *componentNames) {
+	b := schemaBuilder{Config: cfg, Names: names}
+	if _, ok := b.enumValues(indirect(et)); !ok {
+		return
+	}
+	ref := &spec.SchemaRef{Ref: componentRoot + keyFrom(indirect(et), cfg, names), Value: spec.NewSchema()}
+	if schema.Value.Items != nil {
+		schema.Value.Items = withConstraints(ref, schema.Value.Items.Value)
+		return
+	}
+	*schema = *withConstraints(ref, schema.Value)
+}
+
+// withConstraints returns the reference, or a schema of allOf the reference if the schema it replaces
+// has keywords besides its type, e.g. a description, pattern, enum or default, which are kept next to allOf.
+func withConstraints(ref *spec.SchemaRef, replaced *spec.Schema) *spec.SchemaRef {
+	constrained := *replaced
+	constrained.Type = nil
+	if constrained.IsEmpty() && constrained.Default == nil && constrained.Description == "" && constrained.Title == "" &&
+		constrained.Example == nil && !constrained.Deprecated && len(constrained.Extensions) == 0 {
+		return ref
+	}
+	constrained.AllOf = spec.SchemaRefs{ref}
+	return &spec.SchemaRef{Value: &constrained}
+}
+
 func extractExtensions(extensible *map[string]interface{}, extensions restful.ExtensionProperties) {
 	if len(extensions.Extensions) > 0 {
 		for key := range extensions.Extensions {
@@ -215,13 +363,13 @@ func extractExtensions(extensible *map[string]interface{}, extensions restful.Ex
 	}
 }
 
-func buildParameter(r restful.Route, restfulParam *restful.Parameter, pattern string, cfg Config) spec.Parameter {
+func buildParameter(r restful.Route, restfulParam *restful.Parameter, pattern string, cfg Config, names *componentNames) spec.Parameter {
 	p := spec.Parameter{}
 	schema := &spec.SchemaRef{
 		Value: &spec.Schema{},
 	}
 	param := restfulParam.Data()
-	p.In = asParamType(param.Kind)
+	p.In = asParamIn(param)
 
 	if param.AllowMultiple {
 		// If the param is an array apply the validations to the items in it
@@ -230,12 +378,16 @@ func buildParameter(r restful.Route, restfulParam *restful.Parameter, pattern st
 			Value: &spec.Schema{
 				Type:    &spec.Types{param.DataType},
 				Pattern: param.Pattern,
+				Min:     param.Minimum,
+				Max:     param.Maximum,
 			},
 		}
 		if length := param.MinLength; length != nil {
 			schema.Value.Items.Value.MinLength = uint64(*length)
 		}
-		schema.Value.Format = param.CollectionFormat
+		if length := param.MaxLength; length != nil {
+			schema.Value.Items.Value.MaxLength = spec.Uint64Ptr(uint64(*length))
+		}
 		if param.MinItems != nil {
 			schema.Value.MinItems = uint64(*param.MinItems)
 		}
@@ -258,10 +410,15 @@ func buildParameter(r restful.Route, restfulParam *restful.Parameter, pattern st
 
 	// Prefer PossibleValues over deprecated AllowableValues
 	if numPossible := len(param.PossibleValues); numPossible > 0 {
+		// the values of an array are the values of its items
+		valued := schema.Value
+		if param.AllowMultiple {
+			valued = schema.Value.Items.Value
+		}
 		// init Enum to our known size and populate it
-		schema.Value.Enum = make([]interface{}, 0, numPossible)
+		valued.Enum = make([]interface{}, 0, numPossible)
 		for _, value := range param.PossibleValues {
-			schema.Value.Enum = append(schema.Value.Enum, stringAutoType((*schema.Value.Type)[0], value))
+			valued.Enum = append(valued.Enum, stringAutoType((*valued.Type)[0], value))
 		}
 	} else {
 		if numAllowable := len(param.AllowableValues); numAllowable > 0 {
@@ -286,6 +443,12 @@ func buildParameter(r restful.Route, restfulParam *restful.Parameter, pattern st
 	p.Name = param.Name
 	p.Required = param.Required
 	p.AllowEmptyValue = param.AllowEmptyValue
+	if deprecated, ok := param.Extensions[keyParamDeprecated].(bool); ok {
+		p.Deprecated = deprecated
+	}
+	if example, ok := param.Extensions[keyParamExample].(string); ok {
+		p.Example = stringAutoType(param.DataType, example)
+	}
 
 	if param.Kind == restful.PathParameterKind {
 		schema.Value.Pattern = pattern
@@ -293,10 +456,18 @@ func buildParameter(r restful.Route, restfulParam *restful.Parameter, pattern st
 		schema.Value.Pattern = param.Pattern
 	}
 	st := reflect.TypeOf(r.ReadSample)
-	if param.Kind == restful.BodyParameterKind && r.ReadSample != nil && param.DataType == st.String() {
+	if mt, ok := param.Extensions[keyParamModel].(reflect.Type); ok {
+		// struct typed parameters are serialized as deep objects, e.g. filter[name]=x
+		schema = &spec.SchemaRef{
+			Ref:   componentRoot + keyFrom(mt, cfg, names),
+			Value: spec.NewSchema(),
+		}
+		p.Style = spec.SerializationDeepObject
+		p.Explode = spec.BoolPtr(true)
+	} else if param.Kind == restful.BodyParameterKind && r.ReadSample != nil && param.DataType == st.String() {
 		schema = &spec.SchemaRef{Value: spec.NewSchema()}
-		if st.Kind() == reflect.Array || st.Kind() == reflect.Slice {
-			dataTypeName := keyFrom(st.Elem(), cfg)
+		if (st.Kind() == reflect.Array || st.Kind() == reflect.Slice) && !hasOwnSchema(st, cfg) {
+			dataTypeName := keyFrom(st.Elem(), cfg, names)
 			schema.Value.Type = &spec.Types{arrayType}
 			schema.Value.Items = &spec.SchemaRef{
 				Value: spec.NewArraySchema(),
@@ -312,21 +483,21 @@ func buildParameter(r restful.Route, restfulParam *restful.Parameter, pattern st
 			schema.Value.Type = &spec.Types{schemaType.RawType}
 			schema.Value.Format = schemaType.Format
 		} else {
-			dataTypeName := keyFrom(st, cfg)
+			dataTypeName := keyFrom(st, cfg, names)
 			schema.Ref = componentRoot + dataTypeName
 			schema.Value = spec.NewSchema()
 		}
 	} else {
 		if param.AllowMultiple {
-			schema.Value.Type = &spec.Types{arrayType}
-			schema.Value.Items = &spec.SchemaRef{Value: &spec.Schema{}}
-			schema.Value.Items.Value.Type = &spec.Types{param.DataType}
-			schema.Value.Items.Value.Format = param.CollectionFormat
+			schema.Value.Items.Value.Format = param.DataFormat
+			schema.Value.Default = arrayAutoType(param.DataType, param.DefaultValue)
 		} else {
-			schema.Value.Type = &spec.Types{param.DataType}
+			schema.Value.Format = param.DataFormat
+			schema.Value.Default = stringAutoType(param.DataType, param.DefaultValue)
+		}
+		if et, ok := param.Extensions[keyParamType].(reflect.Type); ok {
+			setEnumParamSchema(schema, et, cfg, names)
 		}
-		schema.Value.Default = stringAutoType(param.DataType, param.DefaultValue)
-		schema.Value.Format = param.DataFormat
 	}
 
 	if p.Extensions == nil {
@@ -334,51 +505,77 @@ func buildParameter(r restful.Route, restfulParam *restful.Parameter, pattern st
 	}
 	extractExtensions(&p.Extensions, param.ExtensionProperties)
 	p.Schema = schema
+	setParamStyle(&p, r, param)
 
 	return p
 }
 
-func buildResponse(e restful.ResponseError, cfg Config, products []string) (r spec.Response) {
-	r.Description = new(string)
-	*r.Description = e.Message
-	if e.Model != nil {
-		st := reflect.TypeOf(e.Model)
-		if st.Kind() == reflect.Ptr {
-			// For pointer type, use element type as the key; otherwise we'll
-			// endue with '#/components/schemas/*Type' which violates openapi spec.
-			st = st.Elem()
-		}
-		schema := &spec.SchemaRef{Value: &spec.Schema{}}
-		if st.Kind() == reflect.Array || st.Kind() == reflect.Slice {
-			modelName := keyFrom(st.Elem(), cfg)
-			schema.Value.Type = &spec.Types{arrayType}
-			schema.Value.Items = &spec.SchemaRef{
-				Value: spec.NewArraySchema(),
-			}
-			isPrimitive := isPrimitiveType(modelName)
-			if isPrimitive {
-				mapped := jsonSchemaType(modelName)
-				schema.Value.Items.Value.Type = &spec.Types{mapped}
-			} else {
-				schema.Value.Items.Ref = componentRoot + modelName
-			}
-		} else {
-			modelName := keyFrom(st, cfg)
-			if schema.Value.Type == nil {
-				schema.Value.Type = &spec.Types{}
-			}
-			if isPrimitiveType(modelName) {
-				// If the response is a primitive type, then don't reference any definitions.
-				// Instead, set the schema's "type" to the model name.
-				*schema.Value.Type = append(*(schema.Value.Type), modelName)
-			} else if schemaType, ok := e.Model.(SchemaType); ok {
-				*schema.Value.Type = append(*(schema.Value.Type), schemaType.RawType)
-				schema.Value.Format = schemaType.Format
+// setParamStyle translates the collection format of an array parameter into the style and explode
+// fields of OpenAPI 3. A style declared with ParamStyle takes precedence.
+func setParamStyle(p *spec.Parameter, r restful.Route, param restful.ParameterData) {
+	if param.AllowMultiple {
+		switch param.CollectionFormat {
+		case restful.CollectionFormatCSV.String():
+			if p.In == spec.ParameterInPath || p.In == spec.ParameterInHeader {
+				p.Style = spec.SerializationSimple
 			} else {
-				modelName = keyFrom(st, cfg)
-				schema.Ref = componentRoot + modelName
+				p.Style = spec.SerializationForm
 			}
+			p.Explode = spec.BoolPtr(false)
+		case restful.CollectionFormatSSV.String():
+			p.Style = spec.SerializationSpaceDelimited
+			p.Explode = spec.BoolPtr(false)
+		case restful.CollectionFormatPipes.String():
+			p.Style = spec.SerializationPipeDelimited
+			p.Explode = spec.BoolPtr(false)
+		case restful.CollectionFormatMulti.String():
+			p.Style = spec.SerializationForm
+			p.Explode = spec.BoolPtr(true)
+		}
+		// tsv has no equivalent in OpenAPI 3 and keeps the default style
+	}
+
+	if style, ok := r.Metadata[KeyOpenAPIStyle+"."+param.Name].(Style); ok {
+		if style.Style != "" {
+			p.Style = style.Style
+		}
+		if style.Explode != nil {
+			p.Explode = style.Explode
+		}
+		p.AllowReserved = style.AllowReserved
+	}
+}
+
+// productsOf returns the media types of the content of the response with the given status code,
+// those the route produces unless documented otherwise, see ReturnsContentType.
+func productsOf(r restful.Route, code int) []string {
+	if mediaTypes, ok := r.Metadata[KeyOpenAPIResponseContentType+"."+statusKey(code)].([]string); ok {
+		return mediaTypes
+	}
+	return r.Produces
+}
+
+func buildResponse(e restful.ResponseError, cfg Config, names *componentNames, products []string) (r spec.Response) {
+	r.Description = new(string)
+	*r.Description = e.Message
+	if download, ok := downloadOf(e.Model); ok {
+		r.WithContent(spec.Content{
+			download.MediaType: spec.NewMediaType().WithSchema(spec.NewStringSchema().WithFormat("binary")),
+		})
+		r.Headers = spec.Headers{}
+		if download.Disposition != "" {
+			r.Headers["Content-Disposition"] = &spec.HeaderRef{Value: &spec.Header{Parameter: spec.Parameter{
+				Schema:  spec.NewStringSchema().NewRef(),
+				Example: download.Disposition,
+			}}}
+		}
+		if download.Ranges {
+			r.Headers["Accept-Ranges"] = &spec.HeaderRef{Value: &spec.Header{Parameter: spec.Parameter{
+				Schema: spec.NewStringSchema().WithEnum("bytes").NewRef(),
+			}}}
 		}
+	} else if e.Model != nil {
+		schema := buildModelSchema(e.Model, cfg, names)
 
 		contents := map[string]*spec.MediaType{}
 		for _, product := range products {
@@ -390,10 +587,11 @@ func buildResponse(e restful.ResponseError, cfg Config, products []string) (r sp
 	}
 
 	if len(e.Headers) > 0 {
-		r.Headers = make(map[string]*spec.HeaderRef, len(e.Headers))
+		if r.Headers == nil {
+			r.Headers = make(map[string]*spec.HeaderRef, len(e.Headers))
+		}
 		for k, v := range e.Headers {
-			headerRef := buildHeader(v)
-			r.Headers[k] = &headerRef
+			r.Headers[k] = buildResponseHeader(k, Header{Header: v}, cfg)
 		}
 	}
 
@@ -404,45 +602,193 @@ func buildResponse(e restful.ResponseError, cfg Config, products []string) (r sp
 	return r
 }
 
+// downloadOf returns the Download marker of a response model, with its media type defaulted.
+func downloadOf(model interface{}) (Download, bool) {
+	var download Download
+	switch m := model.(type) {
+	case Download:
+		download = m
+	case *Download:
+		if m == nil {
+			return download, false
+		}
+		download = *m
+	default:
+		return download, false
+	}
+	if download.MediaType == "" {
+		download.MediaType = restful.MIME_OCTET
+	}
+	return download, true
+}
+
+// addRangeResponses documents range requests for operations returning a Download with Ranges:
+// the optional Range header, the 206 response with the requested part and the 416 response.
+func addRangeResponses(o *spec.Operation, r restful.Route) {
+	for code, e := range r.ResponseErrors {
+		download, ok := downloadOf(e.Model)
+		if !ok || !download.Ranges || code < 200 || code > 299 {
+			continue
+		}
+		o.AddParameter(spec.NewHeaderParameter("Range").
+			WithDescription("bytes to return, e.g. bytes=0-1023").
+			WithSchema(spec.NewStringSchema()))
+
+		contentRange := func(example string) *spec.HeaderRef {
+			return &spec.HeaderRef{Value: &spec.Header{Parameter: spec.Parameter{
+				Required: true,
+				Schema:   spec.NewStringSchema().NewRef(),
+				Example:  example,
+			}}}
+		}
+		if o.Responses.Status(http.StatusPartialContent) == nil {
+			partial := spec.NewResponse().
+				WithDescription(http.StatusText(http.StatusPartialContent)).
+				WithContent(spec.Content{
+					download.MediaType: spec.NewMediaType().WithSchema(spec.NewStringSchema().WithFormat("binary")),
+				})
+			partial.Headers = spec.Headers{"Content-Range": contentRange("bytes 0-1023/4096")}
+			o.AddResponse(http.StatusPartialContent, partial)
+		}
+		if o.Responses.Status(http.StatusRequestedRangeNotSatisfiable) == nil {
+			unsatisfiable := spec.NewResponse().
+				WithDescription(http.StatusText(http.StatusRequestedRangeNotSatisfiable))
+			unsatisfiable.Headers = spec.Headers{"Content-Range": contentRange("bytes */4096")}
+			o.AddResponse(http.StatusRequestedRangeNotSatisfiable, unsatisfiable)
+		}
+		return
+	}
+}
+
+// buildModelSchema builds the schema of a sample model, which refers to its component
+// unless it is a primitive, a SchemaType or an array of primitives.
+func buildModelSchema(model interface{}, cfg Config, names *componentNames) *spec.SchemaRef {
+	st := reflect.TypeOf(model)
+	if st.Kind() == reflect.Ptr {
+		// For pointer type, use element type as the key; otherwise we'll
+		// endue with '#/components/schemas/*Type' which violates openapi spec.
+		st = st.Elem()
+	}
+	schema := &spec.SchemaRef{Value: &spec.Schema{}}
+	if (st.Kind() == reflect.Array || st.Kind() == reflect.Slice) && !hasOwnSchema(st, cfg) {
+		modelName := keyFrom(st.Elem(), cfg, names)
+		schema.Value.Type = &spec.Types{arrayType}
+		schema.Value.Items = &spec.SchemaRef{
+			Value: spec.NewArraySchema(),
+		}
+		isPrimitive := isPrimitiveType(modelName)
+		if isPrimitive {
+			mapped := jsonSchemaType(modelName)
+			schema.Value.Items.Value.Type = &spec.Types{mapped}
+		} else {
+			schema.Value.Items.Ref = componentRoot + modelName
+		}
+	} else {
+		modelName := keyFrom(st, cfg, names)
+		if schema.Value.Type == nil {
+			schema.Value.Type = &spec.Types{}
+		}
+		if isPrimitiveType(modelName) {
+			// If the response is a primitive type, then don't reference any definitions.
+			// Instead, set the schema's "type" to the model name.
+			*schema.Value.Type = append(*(schema.Value.Type), modelName)
+		} else if schemaType, ok := model.(SchemaType); ok {
+			*schema.Value.Type = append(*(schema.Value.Type), schemaType.RawType)
+			schema.Value.Format = schemaType.Format
+		} else {
+			modelName = keyFrom(st, cfg, names)
+			schema.Ref = componentRoot + modelName
+		}
+	}
+	return schema
+}
+
 // buildHeader builds a specification header structure from restful.Header
 func buildHeader(header restful.Header) spec.HeaderRef {
 	responseHeader := spec.HeaderRef{
 		Value: &spec.Header{
 			Parameter: spec.Parameter{
-				Schema: &spec.SchemaRef{
-					Value: &spec.Schema{},
-				},
+				Description: header.Description,
+				Schema:      &spec.SchemaRef{Value: spec.NewSchema()},
 			},
 		},
 	}
-	responseHeader.Value.In = header.Type
-	responseHeader.Value.Description = header.Description
-	responseHeader.Value.Schema.Value.Format = header.Format
-	responseHeader.Value.Schema.Value.Default = header.Default
-
-	// If type is "array" items field is required
-	if header.Type == arrayType {
-		responseHeader.Value.Schema.Value.Format = header.CollectionFormat
-		responseHeader.Value.Schema.Value.Items = buildHeadersItems(header.Items)
+	if header.Items != nil {
+		responseHeader.Value.Schema = buildHeadersItems(header.Items)
 	}
-
 	return responseHeader
 }
 
-// buildHeadersItems builds
+// buildHeadersItems builds the schema of a header value, including the items if it is an array.
+// Header arrays are always serialized with the simple style, i.e. comma separated.
 func buildHeadersItems(items *restful.Items) *spec.SchemaRef {
-	responseItems := &spec.SchemaRef{Value: &spec.Schema{}}
+	responseItems := &spec.SchemaRef{Value: spec.NewSchema()}
+	if items.Type != "" {
+		responseItems.Value.Type = &spec.Types{items.Type}
+	}
 	responseItems.Value.Format = items.Format
-	responseItems.Value.Type = &spec.Types{arrayType}
 	responseItems.Value.Default = items.Default
-	responseItems.Value.Format = items.CollectionFormat
-	if items.Items != nil {
-		responseItems.Value.Items = buildHeadersItems(items.Items)
+	if items.Type == arrayType {
+		if items.Items != nil {
+			responseItems.Value.Items = buildHeadersItems(items.Items)
+		} else {
+			responseItems.Value.Items = &spec.SchemaRef{Value: spec.NewStringSchema()}
+		}
 	}
-
 	return responseItems
 }
 
+// buildResponseHeader builds a specification header from Header. A header with the name
+// of one of the Config.Headers refers to the reusable one in components.headers.
+func buildResponseHeader(name string, header Header, cfg Config) *spec.HeaderRef {
+	if _, ok := cfg.Headers[name]; ok {
+		return &spec.HeaderRef{Ref: headerRoot + name}
+	}
+	return buildHeaderFrom(header)
+}
+
+// buildHeaderFrom builds a specification header structure from Header
+func buildHeaderFrom(header Header) *spec.HeaderRef {
+	headerRef := buildHeader(header.Header)
+	headerRef.Value.Required = header.Required
+	headerRef.Value.Deprecated = header.Deprecated
+	headerRef.Value.Example = header.Example
+	return &headerRef
+}
+
+// addResponseHeaders adds the headers declared with ReturnsHeader to the responses of the operation.
+func addResponseHeaders(o *spec.Operation, r restful.Route, cfg Config) {
+	for key, value := range r.Metadata {
+		header, ok := value.(Header)
+		if !ok || !strings.HasPrefix(key, KeyOpenAPIResponseHeader+".") {
+			continue
+		}
+		code, name, _ := strings.Cut(strings.TrimPrefix(key, KeyOpenAPIResponseHeader+"."), ".")
+		rsp := responseOf(o, code)
+		if rsp == nil {
+			continue
+		}
+		if rsp.Value.Headers == nil {
+			rsp.Value.Headers = spec.Headers{}
+		}
+		rsp.Value.Headers[name] = buildResponseHeader(name, header, cfg)
+	}
+}
+
+// responseOf returns the response of the operation for a status code or "default", if declared.
+func responseOf(o *spec.Operation, code string) *spec.ResponseRef {
+	var rsp *spec.ResponseRef
+	if code == "default" {
+		rsp = o.Responses.Default()
+	} else if status, err := strconv.Atoi(code); err == nil {
+		rsp = o.Responses.Status(status)
+	}
+	if rsp == nil || rsp.Value == nil {
+		return nil
+	}
+	return rsp
+}
+
 // stripTags takes a snippet of HTML and returns only the text content.
 // For example, `<b>&lt;Hi!&gt;</b> <br>` -> `&lt;Hi!&gt; `.
 func stripTags(html string) string {
@@ -1,7 +1,10 @@
 package restspec
 
 import (
+	"fmt"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/emicklei/go-restful/v3"
 	spec "github.com/getkin/kin-openapi/openapi3"
@@ -38,7 +41,7 @@ func TestRouteToPath(t *testing.T) {
 		Returns(200, "list of a b tests", []Sample{}).
 		Writes([]Sample{}))
 
-	p := buildPaths(ws, Config{})
+	p := buildPaths(ws, Config{}, nil)
 	t.Log(asJSON(p))
 
 	if op := p.Find("/tests/{v}/a/{b}").Get; (*op.Parameters[0].Value.Schema.Value.Type)[0] != "string" {
@@ -58,7 +61,7 @@ func TestRouteToPath(t *testing.T) {
 	if !exists {
 		t.Errorf("get parameter q failed")
 	}
-	if (*q.Value.Schema.Value.Type)[0] != "array" || (*q.Value.Schema.Value.Items.Value.Type)[0] != "string" || q.Value.Schema.Value.Format != "date" {
+	if (*q.Value.Schema.Value.Type)[0] != "array" || (*q.Value.Schema.Value.Items.Value.Type)[0] != "string" || q.Value.Schema.Value.Items.Value.Format != "date" {
 		t.Errorf("parameter q expected to be a date array")
 	}
 
@@ -153,7 +156,7 @@ func TestGoogleCustomMethods(t *testing.T) {
 		Reads(Sample{}).
 		Writes([]Sample{}))
 
-	p := buildPaths(ws, Config{})
+	p := buildPaths(ws, Config{}, nil)
 	t.Log(asJSON(p))
 
 	path := p.Find("/tests/resource:validate")
@@ -209,7 +212,7 @@ func TestRouteToPathForAllowableValues(t *testing.T) {
 		Returns(200, "list of a b tests", []Sample{}).
 		Writes([]Sample{}))
 
-	p := buildPaths(ws, Config{})
+	p := buildPaths(ws, Config{}, nil)
 	t.Log(asJSON(p))
 
 	if (*p.Find("/tests/{v}/a/{cheese}").Get.Parameters[0].Value.Schema.Value.Type)[0] != "string" {
@@ -266,7 +269,7 @@ func TestMultipleMethodsRouteToPath(t *testing.T) {
 		Reads(Sample{}).
 		Writes([]Sample{}))
 
-	p := buildPaths(ws, Config{})
+	p := buildPaths(ws, Config{}, nil)
 	t.Log(asJSON(p))
 
 	if p.Find("/tests/a/a/b").Get.Summary != "get a b test" {
@@ -304,7 +307,7 @@ func TestReadArrayObjectInBody(t *testing.T) {
 		Reads([]Sample{}).
 		Writes([]Sample{}))
 
-	p := buildPaths(ws, Config{})
+	p := buildPaths(ws, Config{}, nil)
 	//t.Log(asJSON(p))
 
 	postInfo := p.Find("/tests/a/a/b").Post
@@ -350,7 +353,7 @@ func TestWritesPrimitive(t *testing.T) {
 		Returns(200, "sample object", Sample{}).
 		Writes(Sample{}))
 
-	p := buildPaths(ws, Config{})
+	p := buildPaths(ws, Config{}, nil)
 	//t.Log(asJSON(p))
 
 	// Make sure that the operation that returns a primitive type is correct.
@@ -406,7 +409,7 @@ func TestWritesRawSchema(t *testing.T) {
 		Returns(200, "raw schema type", SchemaType{RawType: "file"}).
 		Writes(SchemaType{RawType: "file"}))
 
-	p := buildPaths(ws, Config{})
+	p := buildPaths(ws, Config{}, nil)
 	t.Log(asJSON(p))
 
 	// Make sure that the operation that returns a raw schema type is correct.
@@ -447,7 +450,7 @@ func TestWritesRawSchemaWithFormat(t *testing.T) {
 		Returns(200, "raw schema type", SchemaType{RawType: "string", Format: "binary"}).
 		Writes(SchemaType{RawType: "string", Format: "binary"}))
 
-	p := buildPaths(ws, Config{})
+	p := buildPaths(ws, Config{}, nil)
 	t.Log(asJSON(p))
 
 	// Make sure that the operation that returns a raw schema type is correct.
@@ -494,7 +497,7 @@ func TestReadAndWriteArrayBytesInBody(t *testing.T) {
 		Reads(binaryType).
 		Writes(binaryType))
 
-	p := buildPaths(ws, Config{})
+	p := buildPaths(ws, Config{}, nil)
 	t.Log(asJSON(p))
 
 	postInfo := p.Find("/tests/a/a/b").Post
@@ -546,3 +549,593 @@ func TestReadAndWriteArrayBytesInBody(t *testing.T) {
 		t.Errorf("got %v want %v", got, want)
 	}
 }
+
+func TestRequestBodyOnlyWhenRouteHasOne(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Path("/tests/body")
+	ws.Produces(restful.MIME_JSON)
+	ws.Route(ws.GET("/{id}").To(dummy).
+		Param(ws.PathParameter("id", "identifier")).
+		Returns(200, "sample", Sample{}))
+	ws.Route(ws.DELETE("/{id}").To(dummy).
+		Param(ws.PathParameter("id", "identifier")))
+	ws.Route(ws.POST("").To(dummy).
+		Reads(Sample{}, "the sample to create"))
+	ws.Consumes(restful.MIME_XML)
+	ws.Route(ws.PUT("").To(dummy).
+		Reads(Sample{}))
+
+	bare := new(restful.WebService)
+	bare.Path("/tests/bare")
+	bare.Route(bare.POST("").To(dummy).
+		Reads(Sample{}))
+
+	p := buildPaths(ws, Config{}, nil)
+	t.Log(asJSON(p))
+
+	if body := p.Find("/tests/body/{id}").Get.RequestBody; body != nil {
+		t.Errorf("GET got request body %v want nil", asJSON(body))
+	}
+	if body := p.Find("/tests/body/{id}").Delete.RequestBody; body != nil {
+		t.Errorf("DELETE got request body %v want nil", asJSON(body))
+	}
+
+	post := p.Find("/tests/body").Post.RequestBody
+	if post == nil {
+		t.Fatal("POST request body missing")
+	}
+	if got, want := post.Value.Description, "the sample to create"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := post.Value.Required, true; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	// the route was added before the WebService declared its Consumes
+	if post.Value.Content.Get(restful.MIME_JSON) == nil {
+		t.Errorf("POST request body expected to fall back to %s", restful.MIME_JSON)
+	}
+	dp := buildPaths(ws, Config{DefaultConsumes: []string{restful.MIME_XML}}, nil)
+	if body := dp.Find("/tests/body").Post.RequestBody; body.Value.Content.Get(restful.MIME_XML) == nil {
+		t.Errorf("POST request body expected to fall back to the DefaultConsumes of the config")
+	}
+
+	put := p.Find("/tests/body").Put.RequestBody
+	if put == nil || put.Value.Content.Get(restful.MIME_XML) == nil {
+		t.Errorf("PUT request body expected to use the Consumes of the WebService")
+	}
+
+	bp := buildPaths(bare, Config{}, nil)
+	if body := bp.Find("/tests/bare").Post.RequestBody; body == nil || body.Value.Content.Get(restful.MIME_JSON) == nil {
+		t.Errorf("request body expected to fall back to %s", restful.MIME_JSON)
+	}
+}
+
+func TestFormParametersInRequestBody(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Path("/tests/form")
+	ws.Route(ws.POST("/login").To(dummy).
+		Param(ws.FormParameter("user", "name of the user").Required(true)).
+		Param(ws.FormParameter("remember", "keep the session").DataType("boolean").DefaultValue("false")).
+		Param(ws.FormParameter("lang", "language").PossibleValues([]string{"en", "de"})))
+	ws.Route(ws.POST("/upload").To(dummy).
+		Param(ws.FormParameter("title", "title of the file")).
+		Param(ws.MultiPartFormParameter("file", "the file").DataFormat("binary").Required(true)))
+
+	p := buildPaths(ws, Config{}, nil)
+	t.Log(asJSON(p))
+
+	login := p.Find("/tests/form/login").Post
+	if got, want := len(login.Parameters), 0; got != want {
+		t.Errorf("got %v parameters want %v", got, want)
+	}
+	mt := login.RequestBody.Value.Content.Get(MIME_URLENCODED)
+	if mt == nil {
+		t.Fatalf("expected %s request body", MIME_URLENCODED)
+	}
+	if got, want := login.RequestBody.Value.Required, true; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := fmt.Sprint(mt.Schema.Value.Required), "[user]"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := mt.Schema.Value.Properties["user"].Value.Description, "name of the user"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := mt.Schema.Value.Properties["remember"].Value.Default, false; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := len(mt.Schema.Value.Properties["lang"].Value.Enum), 2; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	upload := p.Find("/tests/form/upload").Post
+	if upload.RequestBody.Value.Content.Get(MIME_URLENCODED) != nil {
+		t.Errorf("unexpected %s request body", MIME_URLENCODED)
+	}
+	mt = upload.RequestBody.Value.Content.Get(MIME_FORMDATA)
+	if mt == nil {
+		t.Fatalf("expected %s request body", MIME_FORMDATA)
+	}
+	if got, want := len(mt.Schema.Value.Properties), 2; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := mt.Schema.Value.Properties["file"].Value.Format, "binary"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+type uploadCoverInput struct {
+	Title  string  `in:"form=title"`
+	Cover  []*File `in:"form=cover;contentType=image/png,image/jpeg;maxItems=3"`
+	Avatar *File   `in:"form=avatar"`
+}
+
+func TestMultipartEncoding(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Path("/tests/multipart")
+	ws.Route(ws.POST("").To(dummy).
+		Do(ReadSample(uploadCoverInput{})).
+		Do(PartEncoding("avatar", Encoding{
+			ContentType: "image/png",
+			Headers: map[string]restful.Header{
+				"X-Checksum": {Items: &restful.Items{Type: "string"}, Description: "checksum of the avatar"},
+			},
+			Required: true,
+		})))
+
+	p := buildPaths(ws, Config{}, nil)
+	t.Log(asJSON(p))
+
+	mt := p.Find("/tests/multipart").Post.RequestBody.Value.Content.Get(MIME_FORMDATA)
+	if mt == nil {
+		t.Fatalf("expected %s request body", MIME_FORMDATA)
+	}
+	cover := mt.Schema.Value.Properties["cover"].Value
+	if !cover.Type.Is("array") || !cover.Items.Value.Type.Is("string") || cover.Items.Value.Format != "binary" {
+		t.Errorf("cover expected to be an array of binary strings, got %v", asJSON(cover))
+	}
+	if cover.MaxItems == nil || *cover.MaxItems != 3 {
+		t.Errorf("cover expected to have at most 3 items")
+	}
+	if got, want := mt.Encoding["cover"].ContentType, "image/png, image/jpeg"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := mt.Encoding["avatar"].ContentType, "image/png"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if _, ok := mt.Encoding["avatar"].Headers["X-Checksum"]; !ok {
+		t.Errorf("avatar expected to document header X-Checksum")
+	}
+	if got, want := fmt.Sprint(mt.Schema.Value.Required), "[avatar]"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+type userFilter struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+type searchUsersInput struct {
+	Filter userFilter `in:"query=filter"`
+}
+
+func TestParameterSerializationStyles(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Path("/tests/styles")
+	ws.Route(ws.GET("").To(dummy).
+		Param(ws.QueryParameter("csv", "").AllowMultiple(true)).
+		Param(ws.QueryParameter("multi", "").AllowMultiple(true).CollectionFormat(restful.CollectionFormatMulti)).
+		Param(ws.QueryParameter("ssv", "").AllowMultiple(true).CollectionFormat(restful.CollectionFormatSSV)).
+		Param(ws.QueryParameter("pipes", "").AllowMultiple(true).CollectionFormat(restful.CollectionFormatPipes)).
+		Param(ws.HeaderParameter("X-Tags", "").AllowMultiple(true).CollectionFormat(restful.CollectionFormatCSV)).
+		Param(ws.QueryParameter("redirect", "")).
+		Do(ReadSample(searchUsersInput{})).
+		Do(ParamStyle("redirect", Style{AllowReserved: true})))
+
+	p := buildPaths(ws, Config{}, nil)
+	t.Log(asJSON(p))
+
+	path := *p.Find("/tests/styles")
+	for name, want := range map[string]string{
+		"csv":      "form false",
+		"multi":    "form true",
+		"ssv":      "spaceDelimited false",
+		"pipes":    "pipeDelimited false",
+		"X-Tags":   "simple false",
+		"filter":   "deepObject true",
+		"redirect": " <nil>",
+	} {
+		param, ok := getParameter(path, name)
+		if !ok {
+			t.Errorf("get parameter %s failed", name)
+			continue
+		}
+		got := param.Value.Style + " "
+		if param.Value.Explode == nil {
+			got += "<nil>"
+		} else {
+			got += fmt.Sprint(*param.Value.Explode)
+		}
+		if got != want {
+			t.Errorf("%s: got %v want %v", name, got, want)
+		}
+		if param.Value.Schema.Value.Format != "" {
+			t.Errorf("%s: unexpected format %s", name, param.Value.Schema.Value.Format)
+		}
+	}
+
+	redirect, _ := getParameter(path, "redirect")
+	if !redirect.Value.AllowReserved {
+		t.Errorf("redirect expected to allow reserved characters")
+	}
+	filter, _ := getParameter(path, "filter")
+	if got, want := filter.Value.Schema.Ref, "#/components/schemas/restspec.userFilter"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	schemas := spec.Schemas{}
+	for _, r := range ws.Routes() {
+		addSchemaFromRouteTo(r, Config{}, nil, &schemas)
+	}
+	if _, ok := schemas["restspec.userFilter"]; !ok {
+		t.Errorf("expected component restspec.userFilter")
+	}
+}
+
+type sessionInput struct {
+	Session string `in:"cookie=session;required"`
+}
+
+func TestCookieParameters(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Path("/tests/cookies")
+	ws.Route(ws.GET("").To(dummy).
+		Do(ReadSample(sessionInput{})).
+		Param(CookieParameter("theme", "ui theme")).
+		Metadata(KeySecurityCookie, "session"))
+
+	p := buildPaths(ws, Config{}, nil)
+	t.Log(asJSON(p))
+
+	path := *p.Find("/tests/cookies")
+	for _, name := range []string{"session", "theme"} {
+		param, ok := getParameter(path, name)
+		if !ok {
+			t.Errorf("get parameter %s failed", name)
+			continue
+		}
+		if got, want := param.Value.In, "cookie"; got != want {
+			t.Errorf("%s: got %v want %v", name, got, want)
+		}
+	}
+	if session, _ := getParameter(path, "session"); session != nil && !session.Value.Required {
+		t.Errorf("session expected to be required")
+	}
+	if got, want := asJSON(path.Get.Security), asJSON(spec.SecurityRequirements{{"session": []string{}}}); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	schemes := buildSecuritySchemes(ws)
+	if scheme, ok := schemes["session"]; !ok {
+		t.Errorf("expected security scheme session")
+	} else if scheme.Value.Type != "apiKey" || scheme.Value.In != "cookie" || scheme.Value.Name != "session" {
+		t.Errorf("unexpected security scheme %v", asJSON(scheme))
+	}
+}
+
+type Paging struct {
+	Page    int `in:"query=page;default=1"`
+	PerPage int `in:"query=per_page;default=20"`
+}
+
+type listOrdersInput struct {
+	Paging
+	Owner struct {
+		Token string `in:"header=x-owner-token;nonzero" description:"token of the owner"`
+	}
+	Since  time.Time `in:"query=since;coder=date"`
+	Tenant string    `in:"tenant=x-tenant;omitempty"`
+	Order  *Sample   `in:"body=xml"`
+}
+
+func TestReadSampleResolverTree(t *testing.T) {
+	RegisterDirective("tenant", restful.HeaderParameter)
+	defer delete(directiveParameters, "tenant")
+
+	ws := new(restful.WebService)
+	ws.Path("/tests/orders")
+	ws.Route(ws.POST("").To(dummy).Do(ReadSample(&listOrdersInput{})))
+
+	r := ws.Routes()[0]
+	params := map[string]restful.ParameterData{}
+	for _, p := range r.ParameterDocs {
+		params[p.Data().Name] = p.Data()
+	}
+	if got, want := len(params), 6; got != want {
+		t.Fatalf("got %v parameters want %v: %v", got, want, asJSON(params))
+	}
+	if got, want := params["per_page"].DefaultValue, "20"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if p := params["x-owner-token"]; !p.Required || p.Description != "token of the owner" || p.Kind != restful.HeaderParameterKind {
+		t.Errorf("unexpected parameter %v", asJSON(p))
+	}
+	if p := params["since"]; p.DataType != "string" || p.Extensions[keyParamModel] != nil {
+		t.Errorf("unexpected parameter %v", asJSON(p))
+	}
+	if p := params["x-tenant"]; p.Required || p.Kind != restful.HeaderParameterKind {
+		t.Errorf("unexpected parameter %v", asJSON(p))
+	}
+	if _, ok := r.ReadSample.(Sample); !ok {
+		t.Errorf("got read sample %T want Sample", r.ReadSample)
+	}
+	if got, want := r.Consumes, []string{restful.MIME_XML}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestResponseHeaders(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Path("/tests/headers")
+	ws.Produces(restful.MIME_JSON)
+	ws.Route(ws.POST("").To(dummy).
+		ReturnsWithHeaders(201, "created", Sample{}, map[string]restful.Header{
+			"Location": {Items: &restful.Items{Type: "string", Format: "uri"}, Description: "url of the sample"},
+			"X-Tags":   {Items: &restful.Items{Type: "array", Items: &restful.Items{Type: "string"}}},
+			"X-Trace":  {Description: "untyped"},
+		}).
+		Returns(429, "too many requests", nil).
+		Do(ReturnsHeader(201, "X-RateLimit-Remaining", Header{
+			Header:   restful.Header{Items: &restful.Items{Type: "integer"}},
+			Required: true,
+			Example:  42,
+		})).
+		Do(ReturnsHeader(429, "Retry-After", Header{})))
+
+	cfg := Config{
+		Headers: map[string]Header{
+			"Retry-After": {Header: restful.Header{Items: &restful.Items{Type: "integer"}, Description: "seconds to wait"}},
+		},
+	}
+	p := buildPaths(ws, cfg, nil)
+	t.Log(asJSON(p))
+
+	created := p.Find("/tests/headers").Post.Responses.Status(201).Value.Headers
+	location := created["Location"].Value
+	if location.In != "" || !location.Schema.Value.Type.Is("string") || location.Schema.Value.Format != "uri" {
+		t.Errorf("unexpected Location header %v", asJSON(location))
+	}
+	tags := created["X-Tags"].Value.Schema.Value
+	if !tags.Type.Is("array") || !tags.Items.Value.Type.Is("string") || tags.Format != "" {
+		t.Errorf("unexpected X-Tags header %v", asJSON(tags))
+	}
+	if created["X-Trace"].Value.Schema == nil {
+		t.Errorf("X-Trace expected to have a schema")
+	}
+	remaining := created["X-RateLimit-Remaining"].Value
+	if !remaining.Required || remaining.Example != 42 || !remaining.Schema.Value.Type.Is("integer") {
+		t.Errorf("unexpected X-RateLimit-Remaining header %v", asJSON(remaining))
+	}
+	retry := p.Find("/tests/headers").Post.Responses.Status(429).Value.Headers["Retry-After"]
+	if got, want := retry.Ref, "#/components/headers/Retry-After"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	openapi := BuildOpenAPIV3(Config{WebServices: []*restful.WebService{ws}, Headers: cfg.Headers})
+	if h, ok := openapi.Components.Headers["Retry-After"]; !ok || h.Value.Description != "seconds to wait" {
+		t.Errorf("expected reusable header Retry-After in components")
+	}
+}
+
+func TestResponseContentTypes(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Path("/tests/content-types")
+	ws.Produces(restful.MIME_JSON, restful.MIME_XML)
+	ws.Route(ws.GET("").To(dummy).
+		Returns(200, "ok", Sample{}).
+		Returns(404, "not found", Item{}).
+		DefaultReturns("failed", Item{}).
+		Do(ReturnsContentType(404, "application/problem+json"), ReturnsContentType(-1, "text/plain")))
+
+	p := buildPaths(ws, Config{}, nil)
+	t.Log(asJSON(p))
+	responses := p.Find("/tests/content-types").Get.Responses
+	for code, want := range map[string][]string{
+		"200":     {restful.MIME_JSON, restful.MIME_XML},
+		"404":     {"application/problem+json"},
+		"default": {"text/plain"},
+	} {
+		content := responses.Value(code).Value.Content
+		if len(content) != len(want) {
+			t.Errorf("%s: unexpected content %v", code, asJSON(content))
+		}
+		for _, mediaType := range want {
+			if content.Get(mediaType) == nil {
+				t.Errorf("%s: missing %s", code, mediaType)
+			}
+		}
+	}
+}
+
+func TestStreamingResponses(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Path("/tests/streams")
+	ws.Produces(restful.MIME_JSON)
+	ws.Route(ws.GET("/events").To(dummy).
+		Do(ReturnsEventStream(200, "events of samples",
+			Event{Name: "created", Data: Sample{}, ID: true, Retry: true},
+			Event{Name: "deleted", Data: ""})))
+	ws.Route(ws.GET("/lines").To(dummy).
+		Do(ReturnsNDJSON(200, "samples", Sample{})))
+	ws.Route(ws.GET("/export").To(dummy).
+		Do(ReturnsStream(200, "export", Stream{MediaType: "text/csv"})))
+	ws.Route(ws.GET("/records").To(dummy).
+		Do(ReturnsStream(200, "records", Stream{MediaType: "text/csv", Item: Sample{}})))
+	ws.Route(ws.GET("/raw").To(dummy).
+		Do(ReturnsStream(200, "raw", Stream{})))
+
+	p := buildPaths(ws, Config{}, nil)
+	t.Log(asJSON(p))
+
+	events := p.Find("/tests/streams/events").Get.Responses.Status(200).Value
+	if got, want := *events.Description, "events of samples"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	sse := events.Content.Get(MIME_EVENT_STREAM)
+	if sse == nil {
+		t.Fatalf("expected %s content", MIME_EVENT_STREAM)
+	}
+	if got, want := sse.Extensions[ExStream], "sse"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	oneOf := sse.Schema.Value.Items.Value.OneOf
+	if got, want := len(oneOf), 2; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	created := oneOf[0].Value
+	if got, want := created.Properties["event"].Value.Enum[0], "created"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := created.Properties["data"].Ref, "#/components/schemas/restspec.Sample"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if created.Properties["id"] == nil || created.Properties["retry"] == nil {
+		t.Errorf("expected id and retry fields")
+	}
+	if !oneOf[1].Value.Properties["data"].Value.Type.Is("string") {
+		t.Errorf("expected string data of event deleted")
+	}
+
+	lines := p.Find("/tests/streams/lines").Get.Responses.Status(200).Value.Content.Get(MIME_NDJSON)
+	if lines == nil || lines.Extensions[ExStream] != "ndjson" || lines.Schema.Value.Items.Ref != "#/components/schemas/restspec.Sample" {
+		t.Errorf("unexpected %s content %v", MIME_NDJSON, asJSON(lines))
+	}
+	export := p.Find("/tests/streams/export").Get.Responses.Status(200).Value.Content.Get("text/csv")
+	if export == nil || export.Extensions[ExStream] != "chunked" || export.Schema.Value.Format != "binary" {
+		t.Errorf("unexpected text/csv content %v", asJSON(export))
+	}
+	records := p.Find("/tests/streams/records").Get.Responses.Status(200).Value.Content.Get("text/csv")
+	if records == nil || records.Extensions[ExStream] != "chunked" || records.Schema.Value.Items.Ref != "#/components/schemas/restspec.Sample" {
+		t.Errorf("unexpected text/csv content %v", asJSON(records))
+	}
+	raw := p.Find("/tests/streams/raw").Get.Responses.Status(200).Value.Content
+	if raw.Get(restful.MIME_OCTET) == nil || raw.Get("") != nil {
+		t.Errorf("expected %s content, got %v", restful.MIME_OCTET, asJSON(raw))
+	}
+
+	schemas := buildSchemas(ws, Config{}, nil)
+	if _, ok := schemas["restspec.Sample"]; !ok {
+		t.Errorf("expected component restspec.Sample")
+	}
+}
+
+func TestDownloadResponses(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Path("/tests/downloads")
+	ws.Produces(restful.MIME_JSON)
+	ws.Route(ws.GET("/raw").To(dummy).
+		Returns(200, "the file", Download{}))
+	ws.Route(ws.GET("/report").To(dummy).
+		Returns(200, "the report", Download{
+			MediaType:   "application/pdf",
+			Disposition: `attachment; filename="report.pdf"`,
+			Ranges:      true,
+		}))
+
+	p := buildPaths(ws, Config{}, nil)
+	t.Log(asJSON(p))
+
+	raw := p.Find("/tests/downloads/raw").Get
+	if got, want := len(raw.Responses.Status(200).Value.Content), 1; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if mt := raw.Responses.Status(200).Value.Content.Get(restful.MIME_OCTET); mt == nil || mt.Schema.Value.Format != "binary" {
+		t.Errorf("expected binary %s content", restful.MIME_OCTET)
+	}
+	if raw.Responses.Status(206) != nil || len(raw.Parameters) != 0 {
+		t.Errorf("unexpected range request documentation")
+	}
+
+	report := p.Find("/tests/downloads/report").Get
+	ok := report.Responses.Status(200).Value
+	if mt := ok.Content.Get("application/pdf"); mt == nil || mt.Schema.Value.Format != "binary" {
+		t.Errorf("expected binary application/pdf content")
+	}
+	if got, want := ok.Headers["Content-Disposition"].Value.Example, `attachment; filename="report.pdf"`; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if _, exists := ok.Headers["Accept-Ranges"]; !exists {
+		t.Errorf("expected Accept-Ranges header")
+	}
+	if param := report.Parameters.GetByInAndName("header", "Range"); param == nil {
+		t.Errorf("expected Range header parameter")
+	}
+	partial := report.Responses.Status(206)
+	if partial == nil || partial.Value.Content.Get("application/pdf") == nil || partial.Value.Headers["Content-Range"] == nil {
+		t.Errorf("expected 206 response with Content-Range header")
+	}
+	if unsatisfiable := report.Responses.Status(416); unsatisfiable == nil || unsatisfiable.Value.Headers["Content-Range"] == nil {
+		t.Errorf("expected 416 response with Content-Range header")
+	}
+	if _, exists := buildSchemas(ws, Config{}, nil)["restspec.Download"]; exists {
+		t.Errorf("unexpected component restspec.Download")
+	}
+}
+
+type subscription struct {
+	CallbackURL string `json:"callbackUrl"`
+}
+
+func TestCallbacksAndLinks(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Path("/tests/subscriptions")
+	ws.Produces(restful.MIME_JSON)
+	ws.Route(ws.POST("").To(dummy).
+		Operation("createSubscription").
+		Reads(subscription{}).
+		Returns(201, "created", subscription{}).
+		Do(Callbacks("onEvent", Callback{
+			Expression: "{$request.body#/callbackUrl}",
+			Doc:        "an event happened",
+			Reads:      Sample{},
+			Returns:    []restful.ResponseError{{Code: 204, Message: "event received"}, {Message: "retried later"}},
+		})).
+		Do(ResponseLink(201, "GetSubscription", Link{
+			OperationID: "getSubscription",
+			Parameters:  map[string]interface{}{"id": "$response.body#/id"},
+		})))
+
+	p := buildPaths(ws, Config{}, nil)
+	t.Log(asJSON(p))
+
+	post := p.Find("/tests/subscriptions").Post
+	callback, ok := post.Callbacks["onEvent"]
+	if !ok {
+		t.Fatal("expected callback onEvent")
+	}
+	pathItem := callback.Value.Value("{$request.body#/callbackUrl}")
+	if pathItem == nil || pathItem.Post == nil {
+		t.Fatal("expected POST to the callback url")
+	}
+	if got, want := pathItem.Post.Summary, "an event happened"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := pathItem.Post.RequestBody.Value.Content.Get(restful.MIME_JSON).Schema.Ref, "#/components/schemas/restspec.Sample"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if pathItem.Post.Responses.Status(204) == nil {
+		t.Errorf("expected 204 response of the callback")
+	}
+	if pathItem.Post.Responses.Default() == nil || pathItem.Post.Responses.Value("0") != nil {
+		t.Errorf("expected default response of the callback, got %v", asJSON(pathItem.Post.Responses))
+	}
+
+	link := post.Responses.Status(201).Value.Links["GetSubscription"]
+	if link == nil || link.Value.OperationID != "getSubscription" || link.Value.Parameters["id"] != "$response.body#/id" {
+		t.Errorf("unexpected link %v", asJSON(link))
+	}
+
+	if _, ok := buildSchemas(ws, Config{}, nil)["restspec.Sample"]; !ok {
+		t.Errorf("expected component restspec.Sample")
+	}
+}
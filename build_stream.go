@@ -0,0 +1,96 @@
+package restspec
+
+import (
+	"strings"
+
+	"github.com/emicklei/go-restful/v3"
+	spec "github.com/getkin/kin-openapi/openapi3"
+)
+
+// ExStream is the extension of a streamed media type, one of "sse", "ndjson" or "chunked".
+// The schema of such a media type is an array of the streamed items, except for chunked streams without Item,
+// which are binary downloads.
+const ExStream = "x-stream"
+
+// addStreamContent adds the media types of the streams declared with ReturnsStream to the responses of the operation.
+func addStreamContent(o *spec.Operation, r restful.Route, cfg Config, names *componentNames) {
+	for key, value := range r.Metadata {
+		stream, ok := value.(Stream)
+		if !ok || !strings.HasPrefix(key, KeyOpenAPIStream+".") {
+			continue
+		}
+		rsp := responseOf(o, strings.TrimPrefix(key, KeyOpenAPIStream+"."))
+		if rsp == nil {
+			continue
+		}
+		if rsp.Value.Content == nil {
+			rsp.Value.Content = spec.Content{}
+		}
+		if stream.MediaType == "" {
+			stream.MediaType = restful.MIME_OCTET
+		}
+		rsp.Value.Content[stream.MediaType] = buildStreamMediaType(stream, cfg, names)
+	}
+}
+
+func buildStreamMediaType(stream Stream, cfg Config, names *componentNames) *spec.MediaType {
+	mt := spec.NewMediaType()
+	mt.Extensions = map[string]interface{}{}
+	var item *spec.SchemaRef
+	switch {
+	case stream.MediaType == MIME_EVENT_STREAM:
+		mt.Extensions[ExStream] = "sse"
+		item = buildEventSchema(stream.Events, cfg, names)
+	case stream.MediaType == MIME_NDJSON:
+		mt.Extensions[ExStream] = "ndjson"
+		item = spec.NewSchemaRef("", spec.NewSchema())
+		if stream.Item != nil {
+			item = buildModelSchema(stream.Item, cfg, names)
+		}
+	case stream.Item != nil:
+		mt.Extensions[ExStream] = "chunked"
+		item = buildModelSchema(stream.Item, cfg, names)
+	default:
+		mt.Extensions[ExStream] = "chunked"
+		return mt.WithSchema(spec.NewStringSchema().WithFormat("binary"))
+	}
+	array := spec.NewArraySchema()
+	array.Items = item
+	return mt.WithSchema(array)
+}
+
+// buildEventSchema builds the schema of a server-sent event, which is one of the given events.
+func buildEventSchema(events []Event, cfg Config, names *componentNames) *spec.SchemaRef {
+	schemas := make(spec.SchemaRefs, 0, len(events))
+	for _, event := range events {
+		schema := spec.NewObjectSchema()
+		schema.Description = event.Description
+		schema.Required = []string{"data"}
+		if event.Name != "" {
+			schema.Title = event.Name
+			schema.Properties["event"] = spec.NewStringSchema().WithEnum(event.Name).NewRef()
+			schema.Required = append([]string{"event"}, schema.Required...)
+		}
+		if event.Data != nil {
+			schema.Properties["data"] = buildModelSchema(event.Data, cfg, names)
+		} else {
+			schema.Properties["data"] = spec.NewStringSchema().NewRef()
+		}
+		if event.ID {
+			schema.Properties["id"] = spec.NewStringSchema().NewRef()
+		}
+		if event.Retry {
+			schema.Properties["retry"] = spec.NewIntegerSchema().NewRef()
+		}
+		schemas = append(schemas, schema.NewRef())
+	}
+	switch len(schemas) {
+	case 0:
+		return spec.NewObjectSchema().WithProperty("data", spec.NewStringSchema()).NewRef()
+	case 1:
+		return schemas[0]
+	}
+	oneOf := spec.NewSchema()
+	oneOf.OneOf = schemas
+	return oneOf.NewRef()
+}
@@ -0,0 +1,263 @@
+// Command restspec-doc generates the registration of the doc comments of a package with restspec,
+// so that these become the descriptions of schemas, properties, enum values and operations.
+// It registers the doc comments of types and their fields, the names and doc comments of constants
+// of the types, and the doc comments of route functions, i.e. func(*restful.Request, *restful.Response).
+//
+// Add to a file of the package:
+//
+//	//go:generate go run github.com/vine-io/go-restful-openapi/cmd/restspec-doc
+//
+// Usage:
+//
+//	restspec-doc [-o file] [directory]
+//
+// The generated file, restspec_doc.go by default, is written into the directory of the package,
+// the current one by default.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	output := flag.String("o", "restspec_doc.go", "name of the generated file")
+	flag.Parse()
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	src, err := generate(dir, *output)
+	if err != nil {
+		log.Fatalf("restspec-doc: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, *output), src, 0o644); err != nil {
+		log.Fatalf("restspec-doc: %v", err)
+	}
+}
+
+// generate returns the source of the file registering the doc comments of the package in dir,
+// skipping test files and the generated file itself.
+func generate(dir, output string) ([]byte, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range names {
+		base := filepath.Base(name)
+		if strings.HasSuffix(base, "_test.go") || base == output {
+			continue
+		}
+		if ok, err := build.Default.MatchFile(dir, base); err != nil || !ok {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) > 0 && f.Name.Name != files[0].Name.Name {
+			return nil, fmt.Errorf("%s: package %s, expected %s", name, f.Name.Name, files[0].Name.Name)
+		}
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s: no Go files", dir)
+	}
+
+	g := &generator{types: map[string]bool{}}
+	for _, f := range files {
+		g.collectTypes(f)
+	}
+	for _, f := range files {
+		g.collect(f)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by restspec-doc. DO NOT EDIT.\n\npackage %s\n\n", files[0].Name.Name)
+	if g.body.Len() > 0 {
+		fmt.Fprintf(&buf, "import restspec %q\n\nfunc init() {\n%s}\n", "github.com/vine-io/go-restful-openapi", g.body.String())
+	}
+	return format.Source(buf.Bytes())
+}
+
+type generator struct {
+	// types are the names of the types of the package which are not generic
+	types map[string]bool
+	body  bytes.Buffer
+}
+
+func (g *generator) collectTypes(f *ast.File) {
+	for _, decl := range f.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.TYPE {
+			for _, spec := range gen.Specs {
+				if ts := spec.(*ast.TypeSpec); ts.TypeParams == nil && ts.Name.Name != "_" {
+					g.types[ts.Name.Name] = true
+				}
+			}
+		}
+	}
+}
+
+func (g *generator) collect(f *ast.File) {
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			switch decl.Tok {
+			case token.TYPE:
+				for _, spec := range decl.Specs {
+					g.typeDoc(spec.(*ast.TypeSpec), decl)
+				}
+			case token.CONST:
+				g.constDocs(decl)
+			}
+		case *ast.FuncDecl:
+			g.funcDoc(decl)
+		}
+	}
+}
+
+// typeDoc registers the doc comments of a type and of the fields of a struct type.
+func (g *generator) typeDoc(ts *ast.TypeSpec, decl *ast.GenDecl) {
+	if !g.types[ts.Name.Name] {
+		return
+	}
+	doc := text(ts.Doc)
+	if doc == "" && len(decl.Specs) == 1 {
+		doc = text(decl.Doc)
+	}
+	var fields bytes.Buffer
+	if st, ok := ts.Type.(*ast.StructType); ok {
+		for _, field := range st.Fields.List {
+			fieldDoc := text(field.Doc)
+			if fieldDoc == "" {
+				fieldDoc = text(field.Comment)
+			}
+			if fieldDoc == "" {
+				continue
+			}
+			for _, name := range fieldNames(field) {
+				fmt.Fprintf(&fields, "%q: %s,\n", name, strconv.Quote(fieldDoc))
+			}
+		}
+	}
+	if doc == "" && fields.Len() == 0 {
+		return
+	}
+	fmt.Fprintf(&g.body, "restspec.RegisterTypeDoc((*%s)(nil), %s, ", ts.Name.Name, strconv.Quote(doc))
+	if fields.Len() == 0 {
+		g.body.WriteString("nil)\n")
+	} else {
+		fmt.Fprintf(&g.body, "map[string]string{\n%s})\n", fields.String())
+	}
+}
+
+// fieldNames returns the names of a field, the name of the type of an embedded one. The doc comment of
+// an embedded struct describes the model, or its component of allOf, see restspec.Config.EmbedAsAllOf.
+func fieldNames(field *ast.Field) (names []string) {
+	for _, name := range field.Names {
+		names = append(names, name.Name)
+	}
+	if len(names) > 0 {
+		return names
+	}
+	expr := field.Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return []string{expr.Name}
+	case *ast.SelectorExpr:
+		return []string{expr.Sel.Name}
+	}
+	return nil
+}
+
+// constDocs registers the names and doc comments of constants of the types of the package.
+// A constant without type and value in a group, e.g. after iota, has the type of the one before.
+func (g *generator) constDocs(decl *ast.GenDecl) {
+	var typ ast.Expr
+	for _, spec := range decl.Specs {
+		vs := spec.(*ast.ValueSpec)
+		if vs.Type != nil || len(vs.Values) > 0 {
+			typ = vs.Type
+		}
+		ident, ok := typ.(*ast.Ident)
+		if !ok || !g.types[ident.Name] {
+			continue
+		}
+		doc := text(vs.Doc)
+		if doc == "" {
+			doc = text(vs.Comment)
+		}
+		for _, name := range vs.Names {
+			if name.Name != "_" {
+				fmt.Fprintf(&g.body, "restspec.RegisterEnumDoc(%s, %q, %s)\n", name.Name, name.Name, strconv.Quote(doc))
+			}
+		}
+	}
+}
+
+// funcDoc registers the doc comment of a route function or method.
+func (g *generator) funcDoc(fd *ast.FuncDecl) {
+	doc := text(fd.Doc)
+	if doc == "" || fd.Name.Name == "_" || fd.Type.TypeParams != nil || !isRouteFunction(fd.Type) {
+		return
+	}
+	expr := fd.Name.Name
+	if fd.Recv != nil && len(fd.Recv.List) == 1 {
+		recv := fd.Recv.List[0].Type
+		star := ""
+		if s, ok := recv.(*ast.StarExpr); ok {
+			recv, star = s.X, "*"
+		}
+		ident, ok := recv.(*ast.Ident)
+		if !ok || !g.types[ident.Name] {
+			// e.g. a method of a generic type
+			return
+		}
+		expr = fmt.Sprintf("(%s%s).%s", star, ident.Name, fd.Name.Name)
+	}
+	fmt.Fprintf(&g.body, "restspec.RegisterFuncDoc(%s, %s)\n", expr, strconv.Quote(doc))
+}
+
+// isRouteFunction reports whether a function is a restful.RouteFunction, func(*restful.Request, *restful.Response).
+func isRouteFunction(ft *ast.FuncType) bool {
+	if ft.Results != nil && len(ft.Results.List) > 0 {
+		return false
+	}
+	var params []string
+	for _, field := range ft.Params.List {
+		star, ok := field.Type.(*ast.StarExpr)
+		if !ok {
+			return false
+		}
+		sel, ok := star.X.(*ast.SelectorExpr)
+		if !ok {
+			return false
+		}
+		for range max(1, len(field.Names)) {
+			params = append(params, sel.Sel.Name)
+		}
+	}
+	return len(params) == 2 && params[0] == "Request" && params[1] == "Response"
+}
+
+// text returns the text of a comment, without surrounding white space.
+func text(doc *ast.CommentGroup) string {
+	return strings.TrimSpace(doc.Text())
+}
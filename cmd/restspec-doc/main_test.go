@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const source = `package shop
+
+import "github.com/emicklei/go-restful/v3"
+
+// Order is an order of a customer
+type Order struct {
+	// ID identifies the order
+	ID    string ` + "`json:\"id\"`" + `
+	State State // state of the order
+	// customer of the order
+	*Customer
+	count int
+}
+
+// Customer places orders
+type Customer struct{}
+
+type State int
+
+const (
+	// Open orders are not paid yet
+	Open State = iota
+	Paid
+	_
+)
+
+const limit = 10
+
+// Page of items
+type Page[T any] struct {
+	Items []T
+}
+
+type resource struct{}
+
+// find finds an order
+func (r *resource) find(req *restful.Request, resp *restful.Response) {}
+
+// list lists orders
+func list(req, resp any) {}
+
+// remove removes an order
+func remove(*restful.Request, *restful.Response) {}
+
+// get of a page
+func (Page[T]) get(req *restful.Request, resp *restful.Response) {}
+`
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"shop.go":          source,
+		"shop_test.go":     "package shop_test\n",
+		"restspec_doc.go":  "package other\n",
+		"ignored_linux.go": "//go:build ignore\n\npackage other\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	src, err := generate(dir, "restspec_doc.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(src)
+	t.Log(got)
+	for _, want := range []string{
+		"// Code generated by restspec-doc. DO NOT EDIT.",
+		"package shop",
+		`restspec.RegisterTypeDoc((*Order)(nil), "Order is an order of a customer", map[string]string{`,
+		`"ID":       "ID identifies the order",`,
+		`"State":    "state of the order",`,
+		`"Customer": "customer of the order",`,
+		`restspec.RegisterTypeDoc((*Customer)(nil), "Customer places orders", nil)`,
+		`restspec.RegisterEnumDoc(Open, "Open", "Open orders are not paid yet")`,
+		`restspec.RegisterEnumDoc(Paid, "Paid", "")`,
+		`restspec.RegisterFuncDoc((*resource).find, "find finds an order")`,
+		`restspec.RegisterFuncDoc(remove, "remove removes an order")`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+	for _, unwanted := range []string{"Page", "limit", "list", `"count"`, `"_"`} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("unexpected %s", unwanted)
+		}
+	}
+}
+
+func TestGenerateWithoutDocs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "plain.go"), []byte("package plain\n\ntype plain struct{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate(dir, "restspec_doc.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(src); strings.Contains(got, "import") || !strings.Contains(got, "package plain") {
+		t.Errorf("unexpected source %s", got)
+	}
+}
@@ -56,10 +56,43 @@ type Config struct {
 	// [optional] If set, model builder should call this handler to get addition typename-to-swagger-format-field conversion.
 	SchemaFormatHandler MapSchemaFormatFunc
 	// [optional] If set, model builder should call this handler to retrieve the name for a given type.
+	//   Its names are used as is, whereas other names are made unique and valid names of components.
 	ModelTypeNameHandler MapModelTypeNameFunc
 	// [optional] If set then call this function with the generated OpenAPI Object
 	PostBuildOpenAPIObjectHandler PostBuildOpenAPIObjectFunc
 	// [optional] If set then call handler's function for to generate name by this handler for definition without json tag,
 	//   you can use you ComponentNameHandler, also, there are four ComponentNameHandler provided, see definition_name.go
 	ComponentNameHandler ComponentNameHandlerFunc
+	// [optional] DefaultConsumes are the MIME types of the request body of routes which consume none,
+	//   neither declared by the route nor by its WebService before the route was added. application/json by default.
+	DefaultConsumes []string
+	// [optional] Reusable response headers added to components.headers by name.
+	//   Response headers with one of these names refer to the reusable header.
+	Headers map[string]Header
+	// [optional] If set, called with the errors of struct tags of models and of the `in` tags of ReadSample
+	//   which can not be parsed, e.g. minimum:"ten", or which httpin rejects. These errors are logged otherwise,
+	//   use IgnoreTagErrors to silence them.
+	TagErrorHandler func(err error)
+	// [optional] Nullability decides which fields of models are documented as nullable,
+	//   by default only those of a Nullable type or with an x-nullable or nullable tag.
+	Nullability Nullability
+	// [optional] Enums holds the values of enum types which can not implement Enum, e.g. of other packages.
+	//   A value may be an EnumValue, see Enum.
+	Enums map[reflect.Type][]any
+	// [optional] Interfaces maps interface types onto their implementations. Values of these types are
+	//   documented as one of the implementations, told apart by a discriminator, see Polymorph.
+	Interfaces map[reflect.Type]Polymorph
+	// [optional] If set, embedded structs are documented as components of allOf next to the own properties
+	//   of a model, instead of flattening their properties into the model.
+	//   The embed tag of a field, "allOf" or "flatten", takes precedence.
+	EmbedAsAllOf bool
+	// [optional] TypeSchemas holds the schemas of types which can not implement SchemaProvider,
+	//   e.g. of other packages. These replace the schemas built by reflection.
+	TypeSchemas map[reflect.Type]*spec.Schema
+	// [optional] If set, called with the names of the model and the field of an anonymous type to name its component,
+	//   which is the name of the model and field by default, e.g. restspec.Order.meta. Types given the same name
+	//   get a suffix in order of the types, e.g. meta and meta_2.
+	AnonymousTypeNameHandler func(modelName, fieldName string) string
+	// [optional] If set, anonymous structs are documented inline as nested objects, instead of components.
+	InlineAnonymousStructs bool
 }
@@ -0,0 +1,93 @@
+package restspec
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// docIndex holds the doc comments of types, fields, enum constants and route functions, registered by
+// the code generated by cmd/restspec-doc. These are the descriptions of last resort, after tags and SwaggerDoc.
+// It has no lock, so it is only written by init functions, before any document is built.
+var docIndex = struct {
+	types  map[reflect.Type]string
+	fields map[reflect.Type]map[string]string
+	values map[reflect.Type]map[any]EnumValue
+	funcs  map[string]string
+}{
+	types:  map[reflect.Type]string{},
+	fields: map[reflect.Type]map[string]string{},
+	values: map[reflect.Type]map[any]EnumValue{},
+	funcs:  map[string]string{},
+}
+
+// RegisterTypeDoc registers the doc comment of a type and those of its fields by Go name, as descriptions
+// of the component of the type and of its properties. The sample is a nil pointer to the type, e.g. (*User)(nil).
+// It may only be called from an init function, as by the generated code.
+func RegisterTypeDoc(sample any, doc string, fields map[string]string) {
+	rt := reflect.TypeOf(sample).Elem()
+	if doc != "" {
+		docIndex.types[rt] = doc
+	}
+	if len(fields) > 0 {
+		docIndex.fields[rt] = fields
+	}
+}
+
+// RegisterEnumDoc registers the name and doc comment of a constant of an enum type, as the name
+// and description of the value where its EnumValue has none, see Enum. Like RegisterTypeDoc, only call it from init.
+func RegisterEnumDoc(value any, name, doc string) {
+	rt := reflect.TypeOf(value)
+	if docIndex.values[rt] == nil {
+		docIndex.values[rt] = map[any]EnumValue{}
+	}
+	docIndex.values[rt][value] = EnumValue{Value: value, Name: name, Description: doc}
+}
+
+// RegisterFuncDoc registers the doc comment of a route function, e.g. (*UserResource).findUser,
+// as description of the operations of routes to the function without notes. Only call it from init.
+func RegisterFuncDoc(fn any, doc string) {
+	docIndex.funcs[funcName(fn)] = doc
+}
+
+// funcName returns the name of a function, the same for a method expression and a method value.
+func funcName(fn any) string {
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func || rv.IsNil() {
+		return ""
+	}
+	f := runtime.FuncForPC(rv.Pointer())
+	if f == nil {
+		return ""
+	}
+	// method values are wrapped by a function of that name with suffix -fm
+	return strings.TrimSuffix(f.Name(), "-fm")
+}
+
+// docOfType returns the doc comment of a type.
+func docOfType(rt reflect.Type) string {
+	return docIndex.types[rt]
+}
+
+// docOfField returns the doc comment of a field of a struct type.
+func docOfField(st reflect.Type, field reflect.StructField) string {
+	return docIndex.fields[st][field.Name]
+}
+
+// enumValueDoc completes a value of an enum type with the name and doc comment of its constant.
+func enumValueDoc(rt reflect.Type, value EnumValue) EnumValue {
+	if rv := reflect.ValueOf(value.Value); !rv.IsValid() || !rv.Comparable() {
+		return value
+	}
+	doc, ok := docIndex.values[rt][value.Value]
+	if !ok {
+		return value
+	}
+	if value.Name == "" {
+		value.Name = doc.Name
+	}
+	if value.Description == "" {
+		value.Description = doc.Description
+	}
+	return value
+}
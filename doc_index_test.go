@@ -0,0 +1,115 @@
+package restspec
+
+import (
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	spec "github.com/getkin/kin-openapi/openapi3"
+)
+
+type Shade string
+
+func (Shade) EnumValues() []any { return []any{"light", Shade("dark")} }
+
+type documented struct {
+	Name   string `json:"name"`
+	Tagged string `json:"tagged" description:"from the tag"`
+	Shade  Shade  `json:"shade"`
+}
+
+type documentedBase struct {
+	ID string `json:"id"`
+}
+
+type documentedEmbed struct {
+	// hello
+	documentedBase
+	Name string `json:"name"`
+}
+
+type documentedOuter struct {
+	documentedEmbed
+}
+
+type documentedResource struct{}
+
+func (documentedResource) find(req *restful.Request, resp *restful.Response) {}
+
+func init() {
+	RegisterTypeDoc((*documented)(nil), "documented is documented", map[string]string{
+		"Name":   "Name is the name",
+		"Tagged": "Tagged is overridden",
+	})
+	RegisterTypeDoc((*Shade)(nil), "Shade of a color", nil)
+	RegisterTypeDoc((*documentedEmbed)(nil), "", map[string]string{"documentedBase": "hello"})
+	RegisterTypeDoc((*documentedOuter)(nil), "documentedOuter is documented", nil)
+	RegisterEnumDoc(Shade("dark"), "Dark", "the dark one")
+	RegisterFuncDoc(documentedResource.find, "find finds")
+}
+
+func TestEmbeddedDocComments(t *testing.T) {
+	// the doc comment of a flattened embedded struct describes the model
+	db := schemaBuilder{Schemas: &spec.Schemas{}, Config: Config{}}
+	db.addModelFrom(documentedEmbed{})
+	t.Log(asJSON(db.Schemas))
+	if sc := (*db.Schemas)["restspec.documentedEmbed"].Value; sc.Description != "hello" || sc.Properties["id"] == nil {
+		t.Errorf("unexpected model %v", asJSON(sc))
+	}
+	// also when flattened in turn
+	db.addModelFrom(documentedOuter{})
+	if got, want := (*db.Schemas)["restspec.documentedOuter"].Value.Description, "documentedOuter is documented\nhello"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+
+	// and its component of allOf
+	db = schemaBuilder{Schemas: &spec.Schemas{}, Config: Config{EmbedAsAllOf: true}}
+	db.addModelFrom(documentedEmbed{})
+	t.Log(asJSON(db.Schemas))
+	sc := (*db.Schemas)["restspec.documentedEmbed"].Value
+	if sc.Description != "" || len(sc.AllOf) != 2 {
+		t.Fatalf("unexpected model %v", asJSON(sc))
+	}
+	if base := sc.AllOf[0].Value; base.Description != "hello" || len(base.AllOf) != 1 || base.AllOf[0].Ref != componentRoot+"restspec.documentedBase" {
+		t.Errorf("unexpected component %v", asJSON(base))
+	}
+}
+
+func TestDocComments(t *testing.T) {
+	db := schemaBuilder{Schemas: &spec.Schemas{}, Config: Config{}}
+	db.addModelFrom(documented{})
+	t.Log(asJSON(db.Schemas))
+
+	sc := (*db.Schemas)["restspec.documented"].Value
+	for _, each := range [][2]string{
+		{sc.Description, "documented is documented"},
+		{sc.Properties["name"].Value.Description, "Name is the name"},
+		{sc.Properties["tagged"].Value.Description, "from the tag"},
+	} {
+		if got, want := each[0], each[1]; got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+	}
+	shade := (*db.Schemas)["restspec.Shade"].Value
+	if got, want := shade.Description, "Shade of a color"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := asJSON(shade.Extensions[ExEnumDescriptions]), asJSON([]string{"", "the dark one"}); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	// not all values are named
+	if _, ok := shade.Extensions[ExEnumVarNames]; ok {
+		t.Errorf("unexpected var names %v", asJSON(shade))
+	}
+
+	ws := new(restful.WebService)
+	ws.Path("/tests/docs")
+	ws.Route(ws.GET("").To(documentedResource{}.find))
+	ws.Route(ws.GET("/notes").To(documentedResource{}.find).Notes("by notes"))
+	p := buildPaths(ws, Config{}, nil)
+	if got, want := p.Find("/tests/docs").Get.Description, "find finds"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := p.Find("/tests/docs/notes").Get.Description, "by notes"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
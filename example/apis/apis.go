@@ -19,6 +19,8 @@ You should have received a copy of the GNU Affero General Public License
 along with this program.  If not, see <https://www.gnu.org/licenses/>.
 */
 
+//go:generate go run github.com/vine-io/go-restful-openapi/cmd/restspec-doc
+
 package apis
 
 import (
@@ -47,6 +49,6 @@ type UpdateUserInput struct {
 	ID        string           `in:"path=id"`
 	UID       string           `in:"query=uid;default=hello"`
 	Languages []int            `in:"form=languages;default=1,2;required"`
-	Cover     []*restspec.File `in:"form=cover"`
+	Cover     []*restspec.File `in:"form=cover;contentType=image/png,image/jpeg;maxItems=3"`
 	//Payload []UserPatch  `in:"body=json"`
 }
@@ -1,3 +1,5 @@
+//go:generate go run github.com/vine-io/go-restful-openapi/cmd/restspec-doc
+
 package internal
 
 type Model struct {
@@ -0,0 +1,10 @@
+// Code generated by restspec-doc. DO NOT EDIT.
+
+package apis
+
+import restspec "github.com/vine-io/go-restful-openapi"
+
+func init() {
+	restspec.RegisterTypeDoc((*User)(nil), "User is just a sample type", nil)
+	restspec.RegisterTypeDoc((*Role)(nil), "Role is just a sample role type", nil)
+}
@@ -1,13 +1,13 @@
 module github.com/vine-io/go-restful-openapi/example
 
-go 1.19
+go 1.23
 
 replace github.com/vine-io/go-restful-openapi => ../
 
 require (
-	github.com/emicklei/go-restful/v3 v3.12.1
-	github.com/getkin/kin-openapi v0.128.0
-	github.com/ggicci/httpin v0.19.0
+	github.com/emicklei/go-restful/v3 v3.12.2
+	github.com/getkin/kin-openapi v0.132.0
+	github.com/ggicci/httpin v0.20.0
 	github.com/vine-io/go-restful-openapi v0.0.0-00010101000000-000000000000
 )
 
@@ -19,6 +19,8 @@ require (
 	github.com/josharian/intern v1.0.0 // indirect
 	github.com/mailru/easyjson v0.7.7 // indirect
 	github.com/mohae/deepcopy v0.0.0-20170929034955-c48cc78d4826 // indirect
+	github.com/oasdiff/yaml v0.0.0-20250309154309-f31be36b4037 // indirect
+	github.com/oasdiff/yaml3 v0.0.0-20250309153720-d2182401db90 // indirect
 	github.com/perimeterx/marshmallow v1.1.5 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -50,11 +50,11 @@ func (u UserResource) WebService() *rest.WebService {
 	})
 	tags := []string{"users"}
 
-	ws.Route(ws.GET("/").Filter(integration.WithFilter(FindUserParams{})).To(u.findAllUsers).
+	ws.Route(ws.GET("/").To(u.findAllUsers).
 		// docs
 		Doc("get all users").
 		Metadata(restspec.KeyOpenAPITags, tags).
-		Do(restspec.ReadSample(FindUserParams{})).
+		Do(integration.Bind[FindUserParams]()).
 		Returns(200, "OK", []apis.User{}))
 
 	ws.Route(ws.GET("/{id}").To(u.findUser).
@@ -70,7 +70,6 @@ func (u UserResource) WebService() *rest.WebService {
 	)
 
 	ws.Route(ws.POST("/{id}").
-		Filter(integration.WithFilter(apis.UpdateUserInput{})).
 		Consumes("multipart/form-data").
 		To(u.updateUser).
 		// docs
@@ -78,7 +77,7 @@ func (u UserResource) WebService() *rest.WebService {
 		Notes("更新用户信息").
 		Metadata(restspec.KeyOpenAPITags, tags).
 		//Reads(apis.UserPatch{}),
-		Do(restspec.ReadSample(apis.UpdateUserInput{})),
+		Do(integration.Bind[apis.UpdateUserInput]()),
 	)
 
 	ws.Route(ws.POST("").To(u.createUser).
@@ -105,7 +104,7 @@ type FindUserParams struct {
 // GET http://localhost:8080/users
 func (u UserResource) findAllUsers(req *rest.Request, rsp *rest.Response) {
 
-	in := integration.GetRequestValue(req).(*FindUserParams)
+	in := integration.Input[FindUserParams](req)
 	fmt.Printf("%#v\n", in)
 
 	list := []apis.User{}
@@ -128,7 +127,7 @@ func (u UserResource) findUser(request *rest.Request, response *rest.Response) {
 
 // PUT http://localhost:8080/users/1
 func (u *UserResource) updateUser(req *rest.Request, rsp *rest.Response) {
-	input := integration.GetRequestValue(req).(*apis.UpdateUserInput)
+	input := integration.Input[apis.UpdateUserInput](req)
 	fmt.Printf("%#v\n", input)
 
 	usr := new(apis.User)
@@ -161,6 +160,9 @@ func (u *UserResource) removeUser(request *rest.Request, response *rest.Response
 }
 
 func main() {
+	// the inputs document parameters with contentType and maxItems
+	integration.UseDocDirectives()
+
 	root := rest.NewContainer()
 	u := UserResource{map[string]apis.User{}}
 	root.Add(u.WebService())
@@ -223,13 +225,11 @@ func enrichOpenAPIObject(swo *restspec.OpenAPI) {
 		},
 	}
 
-	swo.Components.SecuritySchemes = openapi3.SecuritySchemes{
-		"bearerAuth": &openapi3.SecuritySchemeRef{
-			Value: &openapi3.SecurityScheme{
-				Type:         "http",
-				Scheme:       "bearer",
-				BearerFormat: "JWT",
-			},
+	swo.Components.SecuritySchemes["bearerAuth"] = &openapi3.SecuritySchemeRef{
+		Value: &openapi3.SecurityScheme{
+			Type:         "http",
+			Scheme:       "bearer",
+			BearerFormat: "JWT",
 		},
 	}
 }
@@ -6,10 +6,10 @@ require (
 	github.com/emicklei/go-restful/v3 v3.12.2
 	github.com/getkin/kin-openapi v0.132.0
 	github.com/ggicci/httpin v0.20.0
+	github.com/ggicci/owl v0.8.2
 )
 
 require (
-	github.com/ggicci/owl v0.8.2 // indirect
 	github.com/go-openapi/jsonpointer v0.21.0 // indirect
 	github.com/go-openapi/swag v0.23.0 // indirect
 	github.com/josharian/intern v1.0.0 // indirect
@@ -0,0 +1,188 @@
+package restspec
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/ggicci/owl"
+)
+
+const (
+	// keyParamDeprecated is the key of a restful Parameter extension marking the parameter as deprecated.
+	keyParamDeprecated = "openapi.deprecated"
+	// keyParamExample is the key of a restful Parameter extension holding an example value of the parameter.
+	keyParamExample = "openapi.example"
+)
+
+// tagValues splits the value of a directive of the `in` tag which only documents a parameter into its values.
+// Values are separated by commas and surrounding spaces are dropped. A value may be quoted
+// with ' or " to keep commas and spaces, e.g. description='name, or nickname'.
+// A backslash escapes a following quote, comma or backslash and is kept before any other character,
+// so that patterns like ^\d+$ need no escaping. owl splits the tag on ; before, so a value can not hold a ;.
+// The directives httpin executes take their arguments unquoted, see directiveArgs.
+func tagValues(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var (
+		values  []string
+		value   strings.Builder
+		quote   rune
+		escaped bool
+		spaces  int // unquoted spaces, kept only if the value goes on
+	)
+	write := func(c rune) {
+		if value.Len() > 0 {
+			value.WriteString(strings.Repeat(" ", spaces))
+		}
+		spaces = 0
+		value.WriteRune(c)
+	}
+	for _, c := range raw {
+		switch {
+		case escaped:
+			escaped = false
+			if !strings.ContainsRune(`'",\`, c) {
+				write('\\')
+			}
+			write(c)
+		case c == '\\':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				value.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			if value.Len() > 0 {
+				value.WriteString(strings.Repeat(" ", spaces))
+			}
+			spaces = 0
+			quote = c
+		case c == ',':
+			values = append(values, value.String())
+			value.Reset()
+			spaces = 0
+		case c == ' ' || c == '\t':
+			spaces++
+		default:
+			write(c)
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote %c in %q", quote, raw)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash in %q", raw)
+	}
+	return append(values, value.String()), nil
+}
+
+// directiveArgs returns the arguments of a directive httpin executes, e.g. default or query, taken like httpin
+// does, split at commas without quoting: default='a,b' has the arguments 'a and b'.
+func directiveArgs(r *owl.Resolver, name string) ([]string, bool) {
+	d := r.GetDirective(name)
+	if d == nil {
+		return nil, false
+	}
+	return d.Argv, true
+}
+
+// directiveValues returns the values of the directive with the given name, false if the field has no such directive.
+func directiveValues(r *owl.Resolver, name string) ([]string, bool, error) {
+	d := r.GetDirective(name)
+	if d == nil {
+		return nil, false, nil
+	}
+	values, err := tagValues(strings.Join(d.Argv, ","))
+	if err != nil {
+		return nil, true, fmt.Errorf("directive %s: %w", name, err)
+	}
+	return values, true, nil
+}
+
+// directiveValue returns the value of a directive which takes a single value. Unquoted commas are kept,
+// e.g. pattern=^[a-z]{1,3}$.
+func directiveValue(r *owl.Resolver, name string) (string, bool, error) {
+	values, ok, err := directiveValues(r, name)
+	return strings.Join(values, ","), ok, err
+}
+
+// setConstraintsFrom documents the constraints of the `in` tag of a field on the parameter,
+// but those whose directive can not be parsed, whose errors are returned.
+func setConstraintsFrom(param *restful.Parameter, r *owl.Resolver) error {
+	var errs []error
+	if value, ok, err := directiveValue(r, "pattern"); err != nil {
+		errs = append(errs, err)
+	} else if ok {
+		param.Pattern(value)
+	}
+	if values, ok, err := directiveValues(r, "enum"); err != nil {
+		errs = append(errs, err)
+	} else if ok {
+		param.PossibleValues(values)
+	}
+	if value, ok, err := directiveValue(r, "format"); err != nil {
+		errs = append(errs, err)
+	} else if ok {
+		param.DataFormat(value)
+	}
+	if value, ok, err := directiveValue(r, "example"); err != nil {
+		errs = append(errs, err)
+	} else if ok {
+		param.AddExtension(keyParamExample, value)
+	}
+	if value, ok, err := directiveValue(r, "deprecated"); err != nil {
+		errs = append(errs, err)
+	} else if ok {
+		if value == "" {
+			value = "true"
+		}
+		if deprecated, err := strconv.ParseBool(value); err != nil {
+			errs = append(errs, fmt.Errorf("directive deprecated: %w", err))
+		} else {
+			param.AddExtension(keyParamDeprecated, deprecated)
+		}
+	}
+	for _, bound := range []struct {
+		name string
+		set  func(float64)
+	}{
+		{"min", func(v float64) { param.Minimum(v) }},
+		{"max", func(v float64) { param.Maximum(v) }},
+	} {
+		if value, ok, err := directiveValue(r, bound.name); err != nil {
+			errs = append(errs, err)
+		} else if ok {
+			if number, err := strconv.ParseFloat(value, 64); err != nil {
+				errs = append(errs, fmt.Errorf("directive %s: %w", bound.name, err))
+			} else {
+				bound.set(number)
+			}
+		}
+	}
+	for _, count := range []struct {
+		name string
+		set  func(int64)
+	}{
+		{"minLength", func(v int64) { param.MinLength(v) }},
+		{"maxLength", func(v int64) { param.MaxLength(v) }},
+		{"minItems", func(v int64) { param.MinItems(v) }},
+		{"maxItems", func(v int64) { param.MaxItems(v) }},
+	} {
+		if value, ok, err := directiveValue(r, count.name); err != nil {
+			errs = append(errs, err)
+		} else if ok {
+			if number, err := strconv.ParseInt(value, 10, 64); err != nil {
+				errs = append(errs, fmt.Errorf("directive %s: %w", count.name, err))
+			} else {
+				count.set(number)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
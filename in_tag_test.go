@@ -0,0 +1,294 @@
+package restspec
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/ggicci/httpin/core"
+)
+
+func TestTagValues(t *testing.T) {
+	for _, each := range []struct {
+		raw  string
+		want []string
+	}{
+		{"", nil},
+		{"page", []string{"page"}},
+		{"page, page_index", []string{"page", "page_index"}},
+		{"name, or nickname", []string{"name", "or nickname"}},
+		{"'name, or nickname'", []string{"name, or nickname"}},
+		{`"it's here", ' padded '`, []string{"it's here", " padded "}},
+		{`a\,b,c`, []string{"a,b", "c"}},
+		{`^\d+$`, []string{`^\d+$`}},
+		{`'don\'t'`, []string{"don't"}},
+		{"a=b", []string{"a=b"}},
+		{"a,,b", []string{"a", "", "b"}},
+	} {
+		got, err := tagValues(each.raw)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", each.raw, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, each.want) {
+			t.Errorf("%q: got %#v want %#v", each.raw, got, each.want)
+		}
+	}
+
+	for _, raw := range []string{"'open", `trailing\`} {
+		if _, err := tagValues(raw); err == nil {
+			t.Errorf("%q: expected error", raw)
+		}
+	}
+}
+
+type constrainedInput struct {
+	Name  string `in:"query=name;pattern=^[a-z]{2,8}$;description='name, or nickname';example=bob;minLength=2;maxLength=8"`
+	Age   int    `in:"query=age;min=0;max=150;example=42"`
+	Sort  string `in:"query=sort;enum=asc,desc;default=asc"`
+	Since string `in:"header=x-since;format=date-time;deprecated"`
+}
+
+func TestReadSampleConstraints(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Path("/tests/constraints")
+	ws.Route(ws.GET("").To(dummy).Do(ReadSample(constrainedInput{})))
+
+	p := buildPaths(ws, Config{}, nil)
+	t.Log(asJSON(p))
+	path := *p.Find("/tests/constraints")
+
+	name, _ := getParameter(path, "name")
+	if got, want := name.Value.Schema.Value.Pattern, "^[a-z]{2,8}$"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := name.Value.Description, "name, or nickname"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := name.Value.Example, "bob"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if s := name.Value.Schema.Value; s.MinLength != 2 || s.MaxLength == nil || *s.MaxLength != 8 {
+		t.Errorf("unexpected length of %v", asJSON(s))
+	}
+
+	age, _ := getParameter(path, "age")
+	if s := age.Value.Schema.Value; s.Min == nil || *s.Min != 0 || s.Max == nil || *s.Max != 150 {
+		t.Errorf("unexpected range of %v", asJSON(s))
+	}
+	if got, want := age.Value.Example, int64(42); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	sort, _ := getParameter(path, "sort")
+	if got, want := sort.Value.Schema.Value.Enum, []interface{}{"asc", "desc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	since, _ := getParameter(path, "x-since")
+	if !since.Value.Deprecated || since.Value.Schema.Value.Format != "date-time" {
+		t.Errorf("unexpected parameter %v", asJSON(since))
+	}
+}
+
+type invalidConstraintInput struct {
+	Age int `in:"query=age;min=young"`
+}
+
+type unregisteredDirectiveInput struct {
+	Name string `in:"query=name;unknown"`
+}
+
+func TestReadSampleInvalidConstraint(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Path("/tests/invalid")
+	ws.Route(ws.GET("").To(dummy).Do(ReadSample(invalidConstraintInput{})))
+	ws.Route(ws.POST("").To(dummy).Do(ReadSample(unregisteredDirectiveInput{})))
+
+	var errs []string
+	buildPaths(ws, Config{TagErrorHandler: func(err error) {
+		errs = append(errs, err.Error())
+	}}, nil)
+	if got, want := len(errs), 2; got != want {
+		t.Fatalf("got %v errors want %v: %v", got, want, errs)
+	}
+	if !strings.Contains(errs[0], "route GET /tests/invalid/: read sample restspec.invalidConstraintInput: field Age: directive min") {
+		t.Errorf("unexpected error %v", errs[0])
+	}
+	if !strings.Contains(errs[1], `rejected by httpin: unregistered directive: "unknown"`) {
+		t.Errorf("unexpected error %v", errs[1])
+	}
+	// the inputs are documented all the same
+	paths := buildPaths(ws, Config{TagErrorHandler: func(error) {}}, nil)
+	path := paths.Find("/tests/invalid")
+	if age, _ := getParameter(*path, "age"); age == nil || age.Value.Schema.Value.Min != nil {
+		t.Errorf("unexpected age %v", asJSON(age))
+	}
+	if name := path.Post.Parameters.GetByInAndName("query", "name"); name == nil {
+		t.Errorf("missing parameter name")
+	}
+}
+
+type undocumentedByHttpinInput struct {
+	Query   string `in:"query=q;description=what to find;maxLength=20"`
+	Session string `in:"cookie=session;description=id of the session"`
+}
+
+// TestReadSampleWithoutRegisteredDirectives documents an input using directives not registered to httpin,
+// in a test process without the registrations of the tests, see init.
+func TestReadSampleWithoutRegisteredDirectives(t *testing.T) {
+	if os.Getenv(envWithoutDirectives) == "" {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestReadSampleWithoutRegisteredDirectives$", "-test.v")
+		cmd.Env = append(os.Environ(), envWithoutDirectives+"=1")
+		out, err := cmd.CombinedOutput()
+		if err != nil || !strings.Contains(string(out), "--- PASS") {
+			t.Fatalf("%v: %s", err, out)
+		}
+		return
+	}
+
+	ws := new(restful.WebService)
+	ws.Path("/tests/unregistered")
+	ws.Route(ws.GET("").To(dummy).Do(ReadSample(undocumentedByHttpinInput{})))
+
+	var errs []error
+	p := buildPaths(ws, Config{TagErrorHandler: func(err error) { errs = append(errs, err) }}, nil)
+	t.Log(asJSON(p))
+	params := p.Find("/tests/unregistered").Get.Parameters
+	if q := params.GetByInAndName("query", "q"); q == nil || q.Description != "what to find" || *q.Schema.Value.MaxLength != 20 {
+		t.Errorf("unexpected q %v", asJSON(q))
+	}
+	if session := params.GetByInAndName("cookie", "session"); session == nil || session.Description != "id of the session" {
+		t.Errorf("unexpected session %v", asJSON(session))
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "rejected by httpin: unregistered directive") {
+		t.Errorf("unexpected errors %v", errs)
+	}
+}
+
+type language string
+
+type arrayInput struct {
+	IDs       []*int64   `in:"query=ids;default=1,2"`
+	Codes     []language `in:"query=codes;enum=en,de;minItems=1;maxItems=5"`
+	Tags      [3]string  `in:"header=x-tags"`
+	Raw       []byte     `in:"query=raw"`
+	Languages []int      `in:"form=languages;default=1,2;required"`
+}
+
+func TestReadSampleArrays(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Path("/tests/arrays")
+	ws.Route(ws.POST("").To(dummy).Do(ReadSample(arrayInput{})))
+
+	p := buildPaths(ws, Config{}, nil)
+	t.Log(asJSON(p))
+	path := *p.Find("/tests/arrays")
+
+	params := path.Post.Parameters
+	ids := params.GetByInAndName("query", "ids")
+	if s := ids.Schema.Value; !s.Type.Is("array") || !s.Items.Value.Type.Is("integer") {
+		t.Errorf("unexpected schema %v", asJSON(s))
+	} else if got, want := s.Default, []interface{}{int64(1), int64(2)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if ids.Style != "form" || ids.Explode == nil || !*ids.Explode {
+		t.Errorf("unexpected style of %v", asJSON(ids))
+	}
+
+	codes := params.GetByInAndName("query", "codes")
+	if s := codes.Schema.Value; !s.Items.Value.Type.Is("string") || len(s.Items.Value.Enum) != 2 || s.MinItems != 1 || *s.MaxItems != 5 {
+		t.Errorf("unexpected schema %v", asJSON(s))
+	}
+
+	tags := params.GetByInAndName("header", "x-tags")
+	if s := tags.Schema.Value; !s.Type.Is("array") || s.MaxItems == nil || *s.MaxItems != 3 {
+		t.Errorf("unexpected schema %v", asJSON(s))
+	}
+
+	raw := params.GetByInAndName("query", "raw")
+	if s := raw.Schema.Value; !s.Type.Is("string") || s.Format != "byte" {
+		t.Errorf("unexpected schema %v", asJSON(s))
+	}
+
+	form := path.Post.RequestBody.Value.Content.Get(MIME_URLENCODED).Schema.Value
+	languages := form.Properties["languages"].Value
+	if !languages.Type.Is("array") || !languages.Items.Value.Type.Is("integer") {
+		t.Errorf("unexpected schema %v", asJSON(languages))
+	} else if got, want := languages.Default, []interface{}{int64(1), int64(2)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+type validatedInput struct {
+	Email string   `in:"query=email" validate:"required,email"`
+	Limit int      `in:"query=limit" binding:"min=1,max=100"`
+	IDs   []string `in:"query=ids" validate:"max=10,dive,uuid"`
+	Sort  string   `in:"query=sort;enum=name" validate:"oneof=name age"`
+}
+
+func TestReadSampleValidateTags(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Path("/tests/validate")
+	ws.Route(ws.GET("").To(dummy).Do(ReadSample(validatedInput{})))
+
+	p := buildPaths(ws, Config{}, nil)
+	t.Log(asJSON(p))
+	path := *p.Find("/tests/validate")
+
+	email, _ := getParameter(path, "email")
+	if !email.Value.Required || email.Value.Schema.Value.Format != "email" {
+		t.Errorf("unexpected parameter %v", asJSON(email))
+	}
+	limit, _ := getParameter(path, "limit")
+	if s := limit.Value.Schema.Value; *s.Min != 1 || *s.Max != 100 {
+		t.Errorf("unexpected parameter %v", asJSON(limit))
+	}
+	ids, _ := getParameter(path, "ids")
+	if s := ids.Value.Schema.Value; *s.MaxItems != 10 || s.Items.Value.Format != "uuid" {
+		t.Errorf("unexpected parameter %v", asJSON(ids))
+	}
+	// the in tag takes precedence
+	sort, _ := getParameter(path, "sort")
+	if got, want := sort.Value.Schema.Value.Enum, []interface{}{"name"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+type quotedDefaultInput struct {
+	Mode string   `in:"query=mode;default='fast,safe'"`
+	Tags []string `in:"query=tags;default='a,b'"`
+}
+
+func TestReadSampleDefaultsLikeHttpin(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Path("/tests/defaults")
+	ws.Route(ws.GET("").To(dummy).Do(ReadSample(quotedDefaultInput{})))
+	p := buildPaths(ws, Config{}, nil)
+	path := *p.Find("/tests/defaults")
+
+	c, err := core.New(quotedDefaultInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := c.Decode(httptest.NewRequest(http.MethodGet, "/tests/defaults", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := decoded.(*quotedDefaultInput)
+
+	mode, _ := getParameter(path, "mode")
+	if got, want := mode.Value.Schema.Value.Default, input.Mode; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	tags, _ := getParameter(path, "tags")
+	if got, want := fmt.Sprint(tags.Value.Schema.Value.Default), fmt.Sprint(input.Tags); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
@@ -0,0 +1,34 @@
+package integration
+
+import (
+	"net/http"
+
+	rest "github.com/emicklei/go-restful/v3"
+	"github.com/ggicci/httpin/core"
+	restspec "github.com/vine-io/go-restful-openapi"
+)
+
+// Bind decodes each request of the route into an input of type T before the route function is called,
+// and documents the parameters and the body of T and the Problem responses of a request which fails to decode.
+// Use Input to get the decoded input in the route function.
+//
+//	ws.Route(ws.GET("/").To(findAllUsers).Do(integration.Bind[FindUserParams]()))
+func Bind[T any](opts ...core.Option) func(b *rest.RouteBuilder) {
+	var input T
+	filter := WithFilter(input, opts...)
+	return func(b *rest.RouteBuilder) {
+		b.Filter(filter).
+			Do(restspec.ReadSample(input)).
+			Returns(http.StatusBadRequest, http.StatusText(http.StatusBadRequest), Problem{}).
+			Returns(http.StatusUnprocessableEntity, http.StatusText(http.StatusUnprocessableEntity), Problem{}).
+			// ErrorHandler writes the problems as application/problem+json, whatever the route produces
+			Do(restspec.ReturnsContentType(http.StatusBadRequest, MIME_PROBLEM_JSON),
+				restspec.ReturnsContentType(http.StatusUnprocessableEntity, MIME_PROBLEM_JSON))
+	}
+}
+
+// Input returns the input of type T decoded by Bind or WithFilter, nil if the request has none.
+func Input[T any](req *rest.Request) *T {
+	input, _ := GetRequestValue(req).(*T)
+	return input
+}
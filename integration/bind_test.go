@@ -0,0 +1,109 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	rest "github.com/emicklei/go-restful/v3"
+	spec "github.com/getkin/kin-openapi/openapi3"
+	restspec "github.com/vine-io/go-restful-openapi"
+)
+
+type findUsersInput struct {
+	Gender string `in:"query=gender;required"`
+	ID     string `in:"path=id"`
+	Page   int    `in:"query=page"`
+}
+
+func TestBind(t *testing.T) {
+	var got *findUsersInput
+	ws := new(rest.WebService)
+	ws.Path("/users")
+	ws.Route(ws.GET("/{id}").Do(Bind[findUsersInput]()).To(func(req *rest.Request, rsp *rest.Response) {
+		got = Input[findUsersInput](req)
+	}))
+
+	route := ws.Routes()[0]
+	if got, want := len(route.ParameterDocs), 3; got != want {
+		t.Errorf("got %v parameters want %v", got, want)
+	}
+	for _, code := range []int{http.StatusBadRequest, http.StatusUnprocessableEntity} {
+		if _, ok := route.ResponseErrors[code].Model.(Problem); !ok {
+			t.Errorf("expected documented problem response %d, got %v", code, route.ResponseErrors)
+		}
+	}
+
+	container := rest.NewContainer()
+	container.Add(ws)
+
+	rec := httptest.NewRecorder()
+	container.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/42?gender=f", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %v want %v: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if got == nil || got.Gender != "f" || got.ID != "42" {
+		t.Errorf("unexpected input %#v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	container.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	if got, want := rec.Code, http.StatusUnprocessableEntity; got != want {
+		t.Errorf("got status %v want %v", got, want)
+	}
+	if got, want := rec.Header().Get("Content-Type"), MIME_PROBLEM_JSON; got != want {
+		t.Errorf("got content type %v want %v", got, want)
+	}
+
+	rec = httptest.NewRecorder()
+	container.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/42?gender=f&page=first", nil))
+	var problem Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("unexpected body %s: %v", rec.Body, err)
+	}
+	if problem.Status != http.StatusUnprocessableEntity || len(problem.Errors) != 1 {
+		t.Fatalf("unexpected problem %s", rec.Body)
+	}
+	if got, want := problem.Errors[0], (FieldProblem{Field: "page", Source: "query"}); got.Field != want.Field || got.Source != want.Source || got.Reason == "" {
+		t.Errorf("got %#v want %#v", got, want)
+	}
+}
+
+func TestErrorHandlerSourceOfRequiredField(t *testing.T) {
+	ws := new(rest.WebService)
+	ws.Route(ws.GET("/users/{id}").Do(Bind[findUsersInput]()).To(func(*rest.Request, *rest.Response) {}))
+	container := rest.NewContainer()
+	container.Add(ws)
+
+	rec := httptest.NewRecorder()
+	container.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	var problem Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil || len(problem.Errors) != 1 {
+		t.Fatalf("unexpected body %s: %v", rec.Body, err)
+	}
+	if got, want := problem.Errors[0].Field+" "+problem.Errors[0].Source, "gender query"; got != want {
+		t.Errorf("got %v want %v: %s", got, want, rec.Body)
+	}
+}
+
+func TestBindDocumentsProblemJSON(t *testing.T) {
+	ws := new(rest.WebService)
+	ws.Path("/users")
+	ws.Route(ws.GET("/{id}").Produces(rest.MIME_JSON).Do(Bind[findUsersInput]()).To(func(*rest.Request, *rest.Response) {}))
+	ws.Route(ws.DELETE("/{id}").Do(Bind[findUsersInput]()).To(func(*rest.Request, *rest.Response) {}))
+
+	openapi := restspec.BuildOpenAPIV3(restspec.Config{WebServices: []*rest.WebService{ws}})
+	item := openapi.Paths.Find("/users/{id}")
+	for method, o := range map[string]*spec.Operation{"GET": item.Get, "DELETE": item.Delete} {
+		for _, code := range []int{http.StatusBadRequest, http.StatusUnprocessableEntity} {
+			content := o.Responses.Status(code).Value.Content
+			if len(content) != 1 || content.Get(MIME_PROBLEM_JSON) == nil {
+				t.Fatalf("%s %d: unexpected content %v", method, code, content)
+			}
+			if got, want := content.Get(MIME_PROBLEM_JSON).Schema.Ref, "#/components/schemas/integration.Problem"; got != want {
+				t.Errorf("%s %d: got %v want %v", method, code, got, want)
+			}
+		}
+	}
+}
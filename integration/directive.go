@@ -0,0 +1,33 @@
+package integration
+
+import (
+	"sync"
+
+	"github.com/ggicci/httpin/core"
+)
+
+// docDirectives are the directives of the `in` tag which only document a parameter, see restspec.ReadSample.
+var docDirectives = []string{
+	"description", "contentType",
+	"pattern", "enum", "format", "example", "deprecated",
+	"min", "max", "minLength", "maxLength", "minItems", "maxItems",
+}
+
+var useDocDirectives sync.Once
+
+// UseDocDirectives registers the directives of the `in` tag which only document a parameter, e.g. contentType,
+// to httpin as directives which do nothing, so that httpin accepts inputs using them. Call it before the inputs
+// are bound, e.g. by WithFilter. Directives of these names registered before are replaced.
+func UseDocDirectives() {
+	useDocDirectives.Do(func() {
+		for _, name := range docDirectives {
+			core.RegisterDirective(name, docDirective{}, true)
+		}
+	})
+}
+
+// docDirective is the httpin directive executor of the docDirectives.
+type docDirective struct{}
+
+func (docDirective) Decode(*core.DirectiveRuntime) error { return nil }
+func (docDirective) Encode(*core.DirectiveRuntime) error { return nil }
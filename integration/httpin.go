@@ -12,13 +12,16 @@ import (
 
 func init() {
 	useHttpin("path", Vars)
+	core.RegisterDirective("cookie", &httpinCookie{}, true)
 }
 
-// WithFilter converts to a FilterFunction.
+// WithFilter converts to a FilterFunction. A request which fails to decode is answered
+// by ErrorHandler unless another error handler is given with core.WithErrorHandler.
 func WithFilter(input any, opts ...core.Option) rest.FilterFunction {
-	handler := httpin.NewInput(input, opts...)
+	handler := httpin.NewInput(input, append([]core.Option{core.WithErrorHandler(ErrorHandler)}, opts...)...)
 	return func(req *rest.Request, resp *rest.Response, chain *rest.FilterChain) {
 		req.Request = setURLVars(req.Request, req.PathParameters())
+		req.Request = req.Request.WithContext(context.WithValue(req.Request.Context(), responseKey, resp))
 		next := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 			req.Request = r
 			resp.ResponseWriter = rw
@@ -38,6 +41,8 @@ type contextKey int
 
 const (
 	varsKey contextKey = iota
+	// responseKey is the key of the restful Response of the request, used by ErrorHandler
+	responseKey
 )
 
 // Vars returns the route variables for the current request, if any.
@@ -93,3 +98,36 @@ func (h *httpinVarsExtractor) Execute(rtm *core.DirectiveRuntime) error {
 	}
 	return extractor.Extract()
 }
+
+// httpinCookie is the directive executor of "cookie", which extracts values
+// from the cookies of a request.
+type httpinCookie struct{}
+
+func (*httpinCookie) Decode(rtm *core.DirectiveRuntime) error {
+	req := rtm.GetRequest()
+	kvs := make(map[string][]string)
+
+	for _, cookie := range req.Cookies() {
+		kvs[cookie.Name] = append(kvs[cookie.Name], cookie.Value)
+	}
+
+	extractor := &core.FormExtractor{
+		Runtime: rtm,
+		Form: multipart.Form{
+			Value: kvs,
+		},
+	}
+	return extractor.Extract()
+}
+
+func (*httpinCookie) Encode(rtm *core.DirectiveRuntime) error {
+	rb := rtm.GetRequestBuilder()
+	encoder := &core.FormEncoder{
+		Setter: func(key string, value []string) {
+			for _, each := range value {
+				rb.Cookie = append(rb.Cookie, &http.Cookie{Name: key, Value: each})
+			}
+		},
+	}
+	return encoder.Execute(rtm)
+}
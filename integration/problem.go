@@ -0,0 +1,90 @@
+package integration
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	rest "github.com/emicklei/go-restful/v3"
+	"github.com/ggicci/httpin/core"
+	"github.com/ggicci/owl"
+)
+
+// MIME_PROBLEM_JSON is the media type of a Problem, see RFC 9457.
+const MIME_PROBLEM_JSON = "application/problem+json"
+
+// Problem is the problem document written by ErrorHandler when a request cannot be decoded.
+type Problem struct {
+	Title  string         `json:"title" description:"summary of the problem"`
+	Status int            `json:"status" description:"HTTP status code"`
+	Detail string         `json:"detail,omitempty" description:"explanation of the problem"`
+	Errors []FieldProblem `json:"errors,omitempty" description:"invalid fields of the request"`
+}
+
+// FieldProblem describes an invalid field of a request.
+type FieldProblem struct {
+	Field  string `json:"field" description:"name of the value in the request, e.g. the name of a query parameter"`
+	Source string `json:"source" description:"location of the value, e.g. query, header, path, form, cookie or body"`
+	Reason string `json:"reason" description:"reason why the value is invalid"`
+}
+
+// sources are the directives of httpin which locate a value in a request.
+var sources = map[string]bool{
+	"query":  true,
+	"header": true,
+	"path":   true,
+	"form":   true,
+	"cookie": true,
+	"body":   true,
+}
+
+// ErrorHandler is the httpin error handler installed by WithFilter. It writes a Problem with status
+// 422 for an invalid field of the input and 400 for a request which cannot be parsed at all.
+func ErrorHandler(rw http.ResponseWriter, r *http.Request, err error) {
+	problem := problemOf(err)
+	if resp, ok := r.Context().Value(responseKey).(*rest.Response); ok {
+		resp.WriteHeaderAndJson(problem.Status, problem, MIME_PROBLEM_JSON)
+		return
+	}
+	rw.Header().Set("Content-Type", MIME_PROBLEM_JSON)
+	rw.WriteHeader(problem.Status)
+	json.NewEncoder(rw).Encode(problem)
+}
+
+// problemOf returns the Problem describing a decoding error.
+func problemOf(err error) Problem {
+	var fieldErr *core.InvalidFieldError
+	if !errors.As(err, &fieldErr) {
+		return Problem{
+			Title:  http.StatusText(http.StatusBadRequest),
+			Status: http.StatusBadRequest,
+			Detail: err.Error(),
+		}
+	}
+
+	field := FieldProblem{Field: fieldErr.Key, Source: fieldErr.Directive, Reason: fieldErr.ErrorMessage}
+	var resolveErr *owl.ResolveError
+	if errors.As(err, &resolveErr) {
+		for _, d := range resolveErr.Resolver.Directives {
+			if !sources[d.Name] {
+				continue
+			}
+			field.Source = d.Name
+			if field.Field == "" && len(d.Argv) > 0 && d.Name != "body" {
+				field.Field = d.Argv[0]
+			}
+			break
+		}
+		if de := resolveErr.AsDirectiveExecutionError(); de != nil && de.Err != nil {
+			field.Reason = de.Err.Error()
+		}
+	}
+	if field.Field == "" {
+		field.Field = fieldErr.Field
+	}
+	return Problem{
+		Title:  http.StatusText(http.StatusUnprocessableEntity),
+		Status: http.StatusUnprocessableEntity,
+		Errors: []FieldProblem{field},
+	}
+}
@@ -1,15 +1,37 @@
 package restspec
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/emicklei/go-restful/v3"
 	"github.com/ggicci/httpin"
+	"github.com/ggicci/httpin/core"
+	"github.com/ggicci/owl"
 )
 
 type File = httpin.File
 
+const (
+	// keyParamModel is the key of a restful Parameter extension holding the reflect.Type
+	// of a struct typed parameter.
+	keyParamModel = "openapi.model"
+	// keyParamIn is the key of a restful Parameter extension overriding the location
+	// of a parameter for kinds restful does not know about.
+	keyParamIn = "openapi.in"
+	// keyParamType is the key of a restful Parameter extension holding the reflect.Type
+	// of the values of a parameter, e.g. of an enum.
+	keyParamType = "openapi.type"
+	// keyReadSampleError is the key prefix of a restful Route metadata holding the error of reading a sample,
+	// followed by "." and the type of the sample, see ReadSample.
+	keyReadSampleError = "openapi.readSample.error"
+)
+
 func asParamType(kind int) string {
 	switch {
 	case kind == restful.PathParameterKind:
@@ -28,120 +50,290 @@ func asParamType(kind int) string {
 	return ""
 }
 
+// asParamIn returns the location of the parameter, taking locations unknown to restful into account.
+func asParamIn(param restful.ParameterData) string {
+	if in, ok := param.Extensions[keyParamIn].(string); ok {
+		return in
+	}
+	return asParamType(param.Kind)
+}
+
+// CookieParameter creates a new Parameter of kind Cookie for documentation purposes.
+// restful has no cookie kind, so it is a header parameter marked as cookie.
+// It is initialized as not required with string as its DataType.
+func CookieParameter(name, description string) *restful.Parameter {
+	p := restful.HeaderParameter(name, description)
+	p.AddExtension(keyParamIn, "cookie")
+	return p
+}
+
+// DirectiveParameter creates the restful Parameter documenting a value extracted by an httpin
+// directive, e.g. restful.QueryParameter for "query".
+type DirectiveParameter func(name, description string) *restful.Parameter
+
+// directiveParameters are the directives of httpin which extract named values from a request.
+var directiveParameters = map[string]DirectiveParameter{
+	"query":  restful.QueryParameter,
+	"header": restful.HeaderParameter,
+	"path":   restful.PathParameter,
+	"form":   restful.FormParameter,
+	"cookie": CookieParameter,
+}
+
+// RegisterDirective documents the values extracted by a custom httpin directive as parameters
+// created by newParam. Directives which are not registered are ignored by ReadSample.
+//
+//	core.RegisterDirective("jwt", &jwtDirective{})
+//	restspec.RegisterDirective("jwt", restful.HeaderParameter)
+func RegisterDirective(name string, newParam DirectiveParameter) {
+	directiveParameters[name] = newParam
+}
+
+// ReadSample documents the parameters and the body of a route from the httpin input struct of sample,
+// which may also be a pointer. The `in` tags are resolved into the same tree httpin decodes a request with,
+// so nested and embedded structs without directives of their own are documented as well.
+// Besides the directives of httpin, the tag may document a parameter with description, pattern, enum,
+// format, example, deprecated, min, max, minLength, maxLength, minItems, maxItems and, for files, contentType:
+//
+//	Name string `in:"query=name;pattern=^[a-z]{2,8}$;description='name, or nickname';example=bob"`
+//
+// Values of these directives may be quoted to hold commas, see the examples of description above.
+// The directives of httpin, e.g. default, take their values like httpin: split at commas, without quoting.
+// No value can hold a semicolon, which separates the directives.
+//
+// Directives it does not know are skipped. An input httpin rejects, e.g. one using directives which are not
+// registered to httpin (see integration.UseDocDirectives for the directives above), is documented all the same.
+// Such an input, or a tag which can not be parsed, is reported to the TagErrorHandler of the config when
+// the route is documented.
 func ReadSample(sample any) func(b *restful.RouteBuilder) {
 	return func(b *restful.RouteBuilder) {
-		rt := reflect.TypeOf(sample)
-		for i := 0; i < rt.NumField(); i++ {
-			field := rt.Field(i)
-			tag := field.Tag.Get("in")
-			if tag == "" {
-				continue
-			}
+		if err := readSample(b, sample); err != nil {
+			b.Metadata(fmt.Sprintf("%s.%T", keyReadSampleError, sample), fmt.Errorf("read sample %T: %w", sample, err))
+		}
+	}
+}
 
-			in := inMap(strings.Trim(tag, `"`))
-			if _, ok := in["body"]; ok {
-				ft := field.Type
-				if ft.Kind() == reflect.Ptr {
-					ft = ft.Elem()
-				}
-				fv := reflect.New(ft).Elem().Interface()
-				b.Reads(fv)
-			}
-			if text, ok := in["path"]; ok {
-				param := restful.PathParameter(text, "")
-				setParamFrom(param, field, in)
-				b.Param(param)
-			}
-			if text, ok := in["query"]; ok {
-				parts := strings.Split(text, ",")
-				for _, part := range parts {
-					param := restful.QueryParameter(part, "")
-					setParamFrom(param, field, in)
-					b.Param(param)
-				}
-			}
-			if text, ok := in["form"]; ok {
-				parts := strings.Split(text, ",")
-				for _, part := range parts {
-					var param *restful.Parameter
-					if isFileField(field.Type) {
-						param = restful.MultiPartFormParameter(part, "").DataFormat("binary")
-						if desc, ok := in["description"]; ok {
-							param.Description(desc)
-						}
-						if _, ok := in["required"]; ok {
-							param.Required(true)
-						}
-					} else {
-						param = restful.FormParameter(part, "")
-						setParamFrom(param, field, in)
-					}
-					b.Param(param)
-				}
-			}
-			if text, ok := in["header"]; ok {
-				parts := strings.Split(text, ",")
-				for _, part := range parts {
-					param := restful.HeaderParameter(part, "")
-					setParamFrom(param, field, in)
-					b.Param(param)
-				}
-			}
+// readSample documents the input struct of sample from the resolver tree built by owl, the one httpin
+// decodes a request with. httpin checks the directives and coders of the input are registered,
+// which only documenting it does not need, so its error is returned after documenting.
+func readSample(b *restful.RouteBuilder, sample any) error {
+	tree, err := owl.New(sample)
+	if err != nil {
+		return err
+	}
+	err = readResolver(b, tree)
+	if _, rejected := core.New(sample); rejected != nil {
+		err = errors.Join(err, fmt.Errorf("rejected by httpin: %w", rejected))
+	}
+	return err
+}
+
+// reportSampleErrors reports the errors of reading the samples of a route, see ReadSample.
+func reportSampleErrors(r restful.Route, cfg Config) {
+	var keys []string
+	for key := range r.Metadata {
+		if strings.HasPrefix(key, keyReadSampleError+".") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err, ok := r.Metadata[key].(error); ok {
+			reportTagError(cfg, fmt.Errorf("route %s %s: %w", r.Method, r.Path, err))
 		}
 	}
 }
 
-func setParamFrom(param *restful.Parameter, field reflect.StructField, in map[string]string) {
-	var dataType, format string
-	st := field.Type
+// readResolver documents the fields of r. Like httpin, it does not descend into
+// fields which have directives. A field with an error does not keep the others from being documented.
+func readResolver(b *restful.RouteBuilder, r *owl.Resolver) error {
+	var errs []error
+	for _, child := range r.Children {
+		if len(child.Directives) == 0 {
+			errs = append(errs, readResolver(b, child))
+		} else if err := readField(b, child); err != nil {
+			errs = append(errs, fmt.Errorf("field %s: %w", child.PathString(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
 
-	t := st.Kind()
-	if t == reflect.Slice || t == reflect.Array {
-		dataType = arrayType
-		if st.Elem().Kind() == reflect.Uint8 {
-			dataType = "byte"
-		} else {
-			format = st.Elem().Kind().String()
+// readField documents a field of the input struct by its directives. A parameter is documented
+// without the directives which can not be parsed, whose errors are returned.
+func readField(b *restful.RouteBuilder, r *owl.Resolver) error {
+	field := r.Field
+	if values, ok := directiveArgs(r, "body"); ok {
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		b.Reads(reflect.New(ft).Elem().Interface())
+		if len(values) > 0 && strings.EqualFold(values[0], "xml") {
+			b.Consumes(restful.MIME_XML)
+		}
+	}
+	var errs []error
+	for _, d := range r.Directives {
+		newParam, ok := directiveParameters[d.Name]
+		if !ok {
+			continue
 		}
-	} else {
-		dataType = st.String()
-		if len(st.Name()) == 0 { // unnamed type
-			// If it is an array, remove the leading []
-			dataType = strings.TrimPrefix(dataType, "[]")
-			// Swagger UI has special meaning for [
-			dataType = strings.Replace(dataType, "[]", "||", -1)
+		for _, name := range d.Argv {
+			if name == "" {
+				continue
+			}
+			var (
+				param *restful.Parameter
+				err   error
+			)
+			if d.Name == "form" && isFileField(field.Type) {
+				param = restful.MultiPartFormParameter(name, "").DataFormat("binary")
+				err = setFileParamFrom(param, r)
+			} else {
+				param = newParam(name, "")
+				err = setParamFrom(param, r)
+			}
+			errs = append(errs, err)
+			b.Param(param)
 		}
 	}
+	return errors.Join(errs...)
+}
 
-	param.DataType(jsonSchemaType(dataType))
-	if len(format) != 0 {
-		param.DataFormat(jsonSchemaFormat(format))
+func setParamFrom(param *restful.Parameter, r *owl.Resolver) error {
+	st := r.Field.Type
+	if st.Kind() == reflect.Ptr {
+		st = st.Elem()
 	}
 
-	if desc, ok := in["description"]; ok {
-		param.Description(desc)
+	switch kind := st.Kind(); {
+	case r.GetDirective("coder") != nil || r.GetDirective("decoder") != nil:
+		// A field decoded by a custom coder is sent as a string, whatever its type
+		param.DataType("string")
+	case (kind == reflect.Slice || kind == reflect.Array) && st.Elem().Kind() == reflect.Uint8:
+		param.DataType("string").DataFormat("byte")
+	case kind == reflect.Slice || kind == reflect.Array:
+		// The data type of an array parameter is the type of its items
+		param.AllowMultiple(true).DataType(paramDataType(st.Elem()))
+		param.AddExtension(keyParamType, st.Elem())
+		if kind == reflect.Array {
+			param.MaxItems(int64(st.Len()))
+		}
+		if k := param.Kind(); k == restful.QueryParameterKind || k == restful.FormParameterKind {
+			// httpin takes the values from repeated parameters, e.g. ids=1&ids=2
+			param.CollectionFormat(restful.CollectionFormatMulti)
+		}
+	default:
+		param.DataType(paramDataType(st))
+		param.AddExtension(keyParamType, st)
+		if mt, ok := structTypeOf(st); ok && param.Kind() == restful.QueryParameterKind {
+			param.DataType("object")
+			param.AddExtension(keyParamModel, mt)
+		}
 	}
-	if defaultValue, ok := in["default"]; ok {
-		param.DefaultValue(defaultValue)
+
+	descErr := setDescriptionFrom(param, r)
+	if values, ok := directiveArgs(r, "default"); ok && len(values) > 0 {
+		// httpin sets a list from all values and other fields from the first one
+		if param.Data().AllowMultiple {
+			param.DefaultValue(strings.Join(values, ","))
+		} else {
+			param.DefaultValue(values[0])
+		}
 	}
-	if _, ok := in["required"]; ok {
+	if r.GetDirective("required") != nil || r.GetDirective("nonzero") != nil {
 		param.Required(true)
 	}
+	setValidateParamFrom(param, r.Field)
+	return errors.Join(descErr, setConstraintsFrom(param, r))
 }
 
-func inMap(text string) map[string]string {
-	maps := make(map[string]string)
-	parts := strings.Split(strings.TrimSpace(text), ";")
-	for _, part := range parts {
-		cuts := strings.Split(strings.TrimSpace(part), "=")
-		var value string
-		if len(cuts) > 1 {
-			value = cuts[1]
+// setFileParamFrom documents a multipart part holding one or more files.
+// A slice of files becomes an array of binary strings limited by maxItems, and
+// contentType lists the content types allowed for the part.
+func setFileParamFrom(param *restful.Parameter, r *owl.Resolver) error {
+	if t := r.Field.Type; t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		param.AllowMultiple(true)
+		if t.Kind() == reflect.Array {
+			param.MaxItems(int64(t.Len()))
+		}
+	}
+	errs := []error{setDescriptionFrom(param, r)}
+	if r.GetDirective("required") != nil || r.GetDirective("nonzero") != nil {
+		param.Required(true)
+	}
+	if value, ok, err := directiveValue(r, "maxItems"); err != nil {
+		errs = append(errs, err)
+	} else if ok {
+		if maxItems, err := strconv.ParseInt(value, 10, 64); err != nil {
+			errs = append(errs, fmt.Errorf("directive maxItems: %w", err))
+		} else {
+			param.MaxItems(maxItems)
 		}
-		maps[cuts[0]] = value
 	}
+	if values, ok, err := directiveValues(r, "contentType"); err != nil {
+		errs = append(errs, err)
+	} else if ok {
+		param.AddExtension(KeyOpenAPIEncoding, Encoding{
+			ContentType: strings.Join(values, ", "),
+		})
+	}
+	return errors.Join(errs...)
+}
 
-	return maps
+// setDescriptionFrom takes the description of a parameter from the description directive
+// or, like the properties of a model, from the description tag of the field.
+func setDescriptionFrom(param *restful.Parameter, r *owl.Resolver) error {
+	if value, ok, err := directiveValue(r, "description"); err != nil {
+		return err
+	} else if ok {
+		param.Description(value)
+	} else if desc, ok := r.Field.Tag.Lookup("description"); ok {
+		param.Description(desc)
+	}
+	return nil
+}
+
+// paramDataType returns the data type of a parameter holding values of type rt.
+// Named types of a basic kind, e.g. type Gender string, are documented by their kind.
+func paramDataType(rt reflect.Type) string {
+	if rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	switch rt.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	}
+	if rt == reflect.TypeOf(time.Time{}) {
+		return "string"
+	}
+
+	dataType := rt.String()
+	if len(rt.Name()) == 0 { // unnamed type
+		// If it is an array, remove the leading []
+		dataType = strings.TrimPrefix(dataType, "[]")
+		// Swagger UI has special meaning for [
+		dataType = strings.Replace(dataType, "[]", "||", -1)
+	}
+	return jsonSchemaType(dataType)
+}
+
+// structTypeOf returns the struct type of a field which is documented as an object.
+func structTypeOf(rt reflect.Type) (reflect.Type, bool) {
+	if rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct || rt == reflect.TypeOf(time.Time{}) {
+		return nil, false
+	}
+	return rt, true
 }
 
 func isFileField(rt reflect.Type) bool {
@@ -151,5 +343,5 @@ func isFileField(rt reflect.Type) bool {
 	if rt.Kind() == reflect.Slice || rt.Kind() == reflect.Array {
 		return isFileField(rt.Elem())
 	}
-	return rt.Kind() == reflect.TypeOf(httpin.File{}).Kind()
+	return rt == reflect.TypeOf(httpin.File{})
 }
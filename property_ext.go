@@ -1,6 +1,9 @@
 package restspec
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
@@ -21,20 +24,34 @@ func setDescription(prop *spec.Schema, field reflect.StructField) {
 	}
 }
 
-func setDefaultValue(prop *spec.Schema, field reflect.StructField) {
+func setTitle(prop *spec.Schema, field reflect.StructField) {
+	if tag := field.Tag.Get("title"); tag != "" {
+		prop.Title = tag
+	}
+}
+
+func setDefaultValue(prop *spec.Schema, field reflect.StructField) error {
 	if tag := field.Tag.Get("default"); tag != "" {
-		prop.Default = stringAutoType("", tag)
+		value, err := typedValue(tag, field)
+		if err != nil {
+			return err
+		}
+		prop.Default = value
 	}
+	return nil
 }
 
-func setIsNullableValue(prop *spec.Schema, field reflect.StructField) {
+// setIsNullableValue keeps the x-nullable tag of OpenAPI 2.0 as extension and takes the nullable keyword
+// of OpenAPI 3.0 from it.
+func setIsNullableValue(prop *spec.Schema, field reflect.StructField) error {
+	if err := setFlag(field, "x-nullable", &prop.Nullable); err != nil {
+		return err
+	}
 	if tag := field.Tag.Get("x-nullable"); tag != "" {
 		initPropExtensions(&prop.Extensions)
-
-		value, err := strconv.ParseBool(tag)
-
-		prop.Extensions["x-nullable"] = value && err == nil
+		prop.Extensions["x-nullable"] = prop.Nullable
 	}
+	return nil
 }
 
 func setGoNameValue(prop *spec.Schema, field reflect.StructField) {
@@ -45,16 +62,26 @@ func setGoNameValue(prop *spec.Schema, field reflect.StructField) {
 	}
 }
 
-func setEnumValues(prop *spec.Schema, field reflect.StructField) {
+func setEnumValues(prop *spec.Schema, field reflect.StructField) error {
 	// We use | to separate the enum values.  This value is chosen
 	// since it's unlikely to be useful in actual enumeration values.
 	if tag := field.Tag.Get("enum"); tag != "" {
+		// each value of a list is one of its items
+		itemField := field
+		if rt := indirect(field.Type); (rt.Kind() == reflect.Slice || rt.Kind() == reflect.Array) && rt.Elem().Kind() != reflect.Uint8 {
+			itemField.Type = rt.Elem()
+		}
 		enums := []interface{}{}
 		for _, s := range strings.Split(tag, "|") {
-			enums = append(enums, s)
+			value, err := typedValue(s, itemField)
+			if err != nil {
+				return err
+			}
+			enums = append(enums, value)
 		}
 		prop.Enum = enums
 	}
+	return nil
 }
 
 func setFormat(prop *spec.Schema, field reflect.StructField) {
@@ -64,22 +91,87 @@ func setFormat(prop *spec.Schema, field reflect.StructField) {
 
 }
 
-func setMaximum(prop *spec.Schema, field reflect.StructField) {
+func setPattern(prop *spec.Schema, field reflect.StructField) {
+	if tag := field.Tag.Get("pattern"); tag != "" {
+		prop.Pattern = tag
+	}
+}
+
+func setMaximum(prop *spec.Schema, field reflect.StructField) error {
 	if tag := field.Tag.Get("maximum"); tag != "" {
 		value, err := strconv.ParseFloat(tag, 64)
-		if err == nil {
-			prop.Max = &value
+		if err != nil {
+			return err
 		}
+		prop.Max = &value
 	}
+	return nil
 }
 
-func setMinimum(prop *spec.Schema, field reflect.StructField) {
+func setMinimum(prop *spec.Schema, field reflect.StructField) error {
 	if tag := field.Tag.Get("minimum"); tag != "" {
 		value, err := strconv.ParseFloat(tag, 64)
-		if err == nil {
-			prop.Min = &value
+		if err != nil {
+			return err
 		}
+		prop.Min = &value
+	}
+	return nil
+}
+
+// setExclusiveBound sets an exclusive bound, either as a boolean modifier of the minimum or maximum
+// like OpenAPI 3.0, or as the bound itself like JSON Schema.
+func setExclusiveBound(tag string, bound **float64, exclusive *bool) error {
+	if tag == "true" || tag == "false" {
+		*exclusive = tag == "true"
+		return nil
+	}
+	value, err := strconv.ParseFloat(tag, 64)
+	if err != nil {
+		return fmt.Errorf("neither a boolean nor a number: %q", tag)
+	}
+	*bound, *exclusive = &value, true
+	return nil
+}
+
+func setExclusiveMinimum(prop *spec.Schema, field reflect.StructField) error {
+	if tag := field.Tag.Get("exclusiveMinimum"); tag != "" {
+		return setExclusiveBound(tag, &prop.Min, &prop.ExclusiveMin)
+	}
+	return nil
+}
+
+func setExclusiveMaximum(prop *spec.Schema, field reflect.StructField) error {
+	if tag := field.Tag.Get("exclusiveMaximum"); tag != "" {
+		return setExclusiveBound(tag, &prop.Max, &prop.ExclusiveMax)
 	}
+	return nil
+}
+
+func setMultipleOf(prop *spec.Schema, field reflect.StructField) error {
+	if tag := field.Tag.Get("multipleOf"); tag != "" {
+		value, err := strconv.ParseFloat(tag, 64)
+		if err != nil {
+			return err
+		}
+		if value <= 0 {
+			return fmt.Errorf("not greater than 0: %v", value)
+		}
+		prop.MultipleOf = &value
+	}
+	return nil
+}
+
+// setCount sets one of the limits of a length, a number of items or a number of properties.
+func setCount(field reflect.StructField, name string, set func(uint64)) error {
+	if tag := field.Tag.Get(name); tag != "" {
+		value, err := strconv.ParseUint(tag, 10, 64)
+		if err != nil {
+			return err
+		}
+		set(value)
+	}
+	return nil
 }
 
 func setType(prop *spec.Schema, field reflect.StructField) {
@@ -105,36 +197,102 @@ func setType(prop *spec.Schema, field reflect.StructField) {
 	}
 }
 
-func setUniqueItems(prop *spec.Schema, field reflect.StructField) {
-	tag := field.Tag.Get("unique")
-	switch tag {
-	case "true":
-		prop.UniqueItems = true
-	case "false":
-		prop.UniqueItems = false
-	}
-}
-
-func setReadOnly(prop *spec.Schema, field reflect.StructField) {
-	tag := field.Tag.Get("readOnly")
-	switch tag {
-	case "true":
-		prop.ReadOnly = true
-	case "false":
-		prop.ReadOnly = false
+// setFlag sets a boolean keyword like readOnly.
+func setFlag(field reflect.StructField, name string, flag *bool) error {
+	if tag := field.Tag.Get(name); tag != "" {
+		value, err := strconv.ParseBool(tag)
+		if err != nil {
+			return err
+		}
+		*flag = value
 	}
+	return nil
 }
 
-func setPropertyMetadata(prop *spec.Schema, field reflect.StructField) {
+func setPropertyMetadata(prop *spec.Schema, field reflect.StructField) error {
 	setDescription(prop, field)
-	setDefaultValue(prop, field)
-	setEnumValues(prop, field)
+	setTitle(prop, field)
 	setFormat(prop, field)
-	setMinimum(prop, field)
-	setMaximum(prop, field)
-	setUniqueItems(prop, field)
+	setPattern(prop, field)
 	setType(prop, field)
-	setReadOnly(prop, field)
-	setIsNullableValue(prop, field)
 	setGoNameValue(prop, field)
+
+	var errs []error
+	check := func(name string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tag %s: %w", name, err))
+		}
+	}
+	check("default", setDefaultValue(prop, field))
+	check("enum", setEnumValues(prop, field))
+	check("minimum", setMinimum(prop, field))
+	check("maximum", setMaximum(prop, field))
+	check("exclusiveMinimum", setExclusiveMinimum(prop, field))
+	check("exclusiveMaximum", setExclusiveMaximum(prop, field))
+	check("multipleOf", setMultipleOf(prop, field))
+	check("minLength", setCount(field, "minLength", func(v uint64) { prop.MinLength = v }))
+	check("maxLength", setCount(field, "maxLength", func(v uint64) { prop.MaxLength = &v }))
+	check("minItems", setCount(field, "minItems", func(v uint64) { prop.MinItems = v }))
+	check("maxItems", setCount(field, "maxItems", func(v uint64) { prop.MaxItems = &v }))
+	check("minProperties", setCount(field, "minProperties", func(v uint64) { prop.MinProps = v }))
+	check("maxProperties", setCount(field, "maxProperties", func(v uint64) { prop.MaxProps = &v }))
+	check("unique", setFlag(field, "unique", &prop.UniqueItems))
+	check("readOnly", setFlag(field, "readOnly", &prop.ReadOnly))
+	check("writeOnly", setFlag(field, "writeOnly", &prop.WriteOnly))
+	check("deprecated", setFlag(field, "deprecated", &prop.Deprecated))
+	check("nullable", setFlag(field, "nullable", &prop.Nullable))
+	check("x-nullable", setIsNullableValue(prop, field))
+	if len(errs) > 0 {
+		return fmt.Errorf("field %s: %w", field.Name, errors.Join(errs...))
+	}
+	return nil
+}
+
+// typedValue parses the text of a default or enum tag into a value of the Go type of the field.
+// A slice takes a JSON array or comma separated items, a struct or map a JSON object.
+// A field with a type tag takes a value of that type instead.
+func typedValue(text string, field reflect.StructField) (interface{}, error) {
+	if tag := field.Tag.Get("type"); tag != "" {
+		return stringAutoType(strings.TrimPrefix(tag, "[]"), text), nil
+	}
+	return valueOfType(text, field.Type)
+}
+
+func valueOfType(text string, rt reflect.Type) (interface{}, error) {
+	rt = indirect(rt)
+	switch rt.Kind() {
+	case reflect.String:
+		return text, nil
+	case reflect.Bool:
+		return strconv.ParseBool(text)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if rt.String() == "time.Duration" {
+			break
+		}
+		return strconv.ParseInt(text, 10, rt.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(text, 10, rt.Bits())
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(text, rt.Bits())
+	case reflect.Slice, reflect.Array:
+		if rt.Elem().Kind() == reflect.Uint8 || strings.HasPrefix(strings.TrimSpace(text), "[") {
+			break
+		}
+		items := []interface{}{}
+		for _, each := range strings.Split(text, ",") {
+			item, err := valueOfType(strings.TrimSpace(each), rt.Elem())
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+
+	// other types take JSON, or a string if they marshal into one, e.g. time.Time
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return text, nil
+	}
+	return value, nil
 }
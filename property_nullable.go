@@ -0,0 +1,100 @@
+package restspec
+
+import (
+	"reflect"
+	"strconv"
+
+	spec "github.com/getkin/kin-openapi/openapi3"
+)
+
+// Nullable is the marker interface of types whose values may be null, e.g. an optional value type.
+// Fields of such types are documented as nullable, whatever the Nullability of the config.
+type Nullable interface {
+	// Nullable reports whether a value of the type may be null
+	Nullable() bool
+}
+
+// Nullability decides which fields of models are documented as nullable, in addition to fields of a
+// Nullable type. The x-nullable and nullable tags of a field take precedence.
+type Nullability struct {
+	// Pointers documents pointer fields as nullable
+	Pointers bool
+	// Wrappers documents fields of sql.Null*-like types, i.e. structs of a value field and a Valid bool field,
+	// as the nullable value
+	Wrappers bool
+	// TypeNull documents nullable fields by adding "null" to the type like OpenAPI 3.1,
+	// instead of nullable: true of OpenAPI 3.0. The document then declares OpenAPI 3.1.0.
+	TypeNull bool
+}
+
+var nullableType = reflect.TypeOf((*Nullable)(nil)).Elem()
+
+// nullWrapperValue returns the value field of a sql.Null*-like type, e.g. String of sql.NullString.
+func nullWrapperValue(rt reflect.Type) (reflect.StructField, bool) {
+	rt = indirect(rt)
+	if rt.Kind() != reflect.Struct || rt.NumField() != 2 {
+		return reflect.StructField{}, false
+	}
+	for i, valid := range []int{1, 0} {
+		if f := rt.Field(valid); f.Name == "Valid" && f.Type.Kind() == reflect.Bool && rt.Field(i).IsExported() {
+			return rt.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// isNullable reports whether the field may be null by its tags, its type and the policy.
+func (n Nullability) isNullable(field reflect.StructField) bool {
+	for _, tag := range []string{"x-nullable", "nullable"} {
+		if value, err := strconv.ParseBool(field.Tag.Get(tag)); err == nil {
+			return value
+		}
+	}
+	if rt := indirect(field.Type); reflect.PointerTo(rt).Implements(nullableType) {
+		return reflect.New(rt).Interface().(Nullable).Nullable()
+	}
+	if n.Pointers && field.Type.Kind() == reflect.Ptr {
+		return true
+	}
+	if _, ok := nullWrapperValue(field.Type); ok && n.Wrappers {
+		return true
+	}
+	return false
+}
+
+// setNullable documents the property of the field as nullable if it may be null.
+// A reference can not be nullable itself, so it becomes the single schema of allOf, or one of oneOf with null.
+func (n Nullability) setNullable(prop *spec.SchemaRef, field reflect.StructField) {
+	if prop.Value == nil {
+		return
+	}
+	nullable := n.isNullable(field)
+	prop.Value.Nullable = false
+	if !nullable {
+		return
+	}
+
+	if prop.Ref != "" {
+		ref := &spec.SchemaRef{Ref: prop.Ref, Value: spec.NewSchema()}
+		// the keywords of the field are kept next to the reference, see withConstraints
+		wrapper := *prop.Value
+		wrapper.Type = nil
+		if n.TypeNull {
+			wrapper.OneOf = spec.SchemaRefs{ref, {Value: &spec.Schema{Type: &spec.Types{"null"}}}}
+		} else {
+			wrapper.AllOf = spec.SchemaRefs{ref}
+			wrapper.Nullable = true
+		}
+		*prop = spec.SchemaRef{Value: &wrapper}
+		return
+	}
+
+	if !n.TypeNull {
+		prop.Value.Nullable = true
+		return
+	}
+	if prop.Value.Type != nil && !prop.Value.Type.Includes("null") {
+		types := append(*prop.Value.Type, "null")
+		prop.Value.Type = &types
+	}
+}
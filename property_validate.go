@@ -0,0 +1,343 @@
+package restspec
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/emicklei/go-restful/v3"
+	spec "github.com/getkin/kin-openapi/openapi3"
+)
+
+// validateTags are the struct tags holding rules of go-playground/validator, used by gin as binding.
+var validateTags = []string{"validate", "binding"}
+
+// validateFormats maps validator rules onto the format of a string.
+var validateFormats = map[string]string{
+	"email":     "email",
+	"uuid":      "uuid",
+	"uuid3":     "uuid",
+	"uuid4":     "uuid",
+	"uuid5":     "uuid",
+	"url":       "uri",
+	"uri":       "uri",
+	"http_url":  "uri",
+	"hostname":  "hostname",
+	"ipv4":      "ipv4",
+	"ipv6":      "ipv6",
+	"datetime":  "date-time",
+	"base64":    "byte",
+	"ulid":      "ulid",
+	"cidr":      "cidr",
+	"mac":       "mac",
+	"hexcolor":  "hexcolor",
+	"latitude":  "latitude",
+	"longitude": "longitude",
+}
+
+// validatePatterns maps validator rules onto the pattern of a string.
+var validatePatterns = map[string]string{
+	"alpha":       `^[a-zA-Z]+$`,
+	"alphanum":    `^[a-zA-Z0-9]+$`,
+	"numeric":     `^[-+]?[0-9]+(?:\.[0-9]+)?$`,
+	"number":      `^[0-9]+$`,
+	"hexadecimal": `^(0[xX])?[0-9a-fA-F]+$`,
+	"lowercase":   `^[^A-Z]*$`,
+	"uppercase":   `^[^a-z]*$`,
+	"e164":        `^\+[1-9]?[0-9]{7,14}$`,
+}
+
+// constraints are the constraints of a value declared by validator rules.
+type constraints struct {
+	required     bool
+	minimum      *float64
+	maximum      *float64
+	exclusiveMin bool
+	exclusiveMax bool
+	minLength    *int64
+	maxLength    *int64
+	minItems     *int64
+	maxItems     *int64
+	unique       bool
+	enum         []string
+	format       string
+	pattern      string
+}
+
+// validateRules returns the rules of the validate and binding tags of a field.
+// Rules following dive apply to the elements of a slice, array or map and are returned as elemRules.
+func validateRules(field reflect.StructField) (rules, elemRules []string) {
+	for _, tag := range validateTags {
+		value := field.Tag.Get(tag)
+		if value == "" || value == "-" {
+			continue
+		}
+		current := &rules
+		skipKeys := false
+		for _, rule := range strings.Split(value, ",") {
+			// a comma in a parameter is written as 0x2C
+			rule = strings.ReplaceAll(strings.TrimSpace(rule), "0x2C", ",")
+			switch {
+			case rule == "dive":
+				current = &elemRules
+			case rule == "keys":
+				skipKeys = true
+			case rule == "endkeys":
+				skipKeys = false
+			case rule != "" && !skipKeys:
+				*current = append(*current, rule)
+			}
+		}
+	}
+	return rules, elemRules
+}
+
+// constraintsOf translates validator rules of a value of type rt into constraints.
+// Rules with alternatives (|) are skipped, as are rules with parameters which do not parse.
+func constraintsOf(rules []string, rt reflect.Type) constraints {
+	kind := indirect(rt).Kind()
+	isString := kind == reflect.String
+	isCollection := kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map
+	isInteger := reflect.Int <= kind && kind <= reflect.Uint64
+	isNumber := isInteger || kind == reflect.Float32 || kind == reflect.Float64
+
+	var c constraints
+	for _, rule := range rules {
+		if strings.Contains(rule, "|") {
+			continue
+		}
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			c.required = true
+		case "unique":
+			c.unique = true
+		case "oneof":
+			c.enum = oneofValues(param)
+		case "startswith":
+			c.pattern = "^" + regexp.QuoteMeta(param)
+		case "endswith":
+			c.pattern = regexp.QuoteMeta(param) + "$"
+		case "contains":
+			c.pattern = regexp.QuoteMeta(param)
+		case "min", "max", "len", "gt", "gte", "lt", "lte":
+			if isNumber {
+				c.setRange(name, param, isInteger)
+			} else if isString || isCollection {
+				c.setLength(name, param, isString)
+			}
+		default:
+			if format, ok := validateFormats[name]; ok {
+				c.format = format
+			} else if pattern, ok := validatePatterns[name]; ok {
+				c.pattern = pattern
+			}
+		}
+	}
+	return c
+}
+
+// setRange sets the minimum or maximum of a number. Bounds of integers are inclusive.
+func (c *constraints) setRange(name, param string, isInteger bool) {
+	value, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	exclusive := name == "gt" || name == "lt"
+	if exclusive && isInteger {
+		if name == "gt" {
+			value++
+		} else {
+			value--
+		}
+		exclusive = false
+	}
+	switch name {
+	case "min", "gte", "gt":
+		c.minimum, c.exclusiveMin = &value, exclusive
+	case "max", "lte", "lt":
+		c.maximum, c.exclusiveMax = &value, exclusive
+	case "len":
+		c.minimum, c.maximum = &value, &value
+	}
+}
+
+// setLength sets the minimum or maximum length of a string or the number of items of a collection.
+func (c *constraints) setLength(name, param string, isString bool) {
+	value, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return
+	}
+	switch name {
+	case "gt":
+		value++
+	case "lt":
+		value--
+	}
+	minimum, maximum := &c.minItems, &c.maxItems
+	if isString {
+		minimum, maximum = &c.minLength, &c.maxLength
+	}
+	switch name {
+	case "min", "gte", "gt":
+		*minimum = &value
+	case "max", "lte", "lt":
+		*maximum = &value
+	case "len":
+		*minimum, *maximum = &value, &value
+	}
+}
+
+// oneofValues splits the parameter of oneof, where values are separated by spaces
+// and may be quoted with ' to hold spaces.
+func oneofValues(param string) (values []string) {
+	for param = strings.TrimSpace(param); param != ""; param = strings.TrimSpace(param) {
+		if param[0] == '\'' {
+			if end := strings.IndexByte(param[1:], '\''); end >= 0 {
+				values = append(values, param[1:end+1])
+				param = param[end+2:]
+				continue
+			}
+		}
+		value, rest, _ := strings.Cut(param, " ")
+		values = append(values, value)
+		param = rest
+	}
+	return values
+}
+
+// applyTo sets the constraints on the schema of a value of type rt.
+func (c constraints) applyTo(prop *spec.Schema, rt reflect.Type) {
+	if c.minimum != nil {
+		prop.Min, prop.ExclusiveMin = c.minimum, c.exclusiveMin
+	}
+	if c.maximum != nil {
+		prop.Max, prop.ExclusiveMax = c.maximum, c.exclusiveMax
+	}
+	if c.minLength != nil {
+		prop.MinLength = uint64(*c.minLength)
+	}
+	if c.maxLength != nil {
+		prop.MaxLength = spec.Uint64Ptr(uint64(*c.maxLength))
+	}
+	if rt.Kind() == reflect.Map {
+		if c.minItems != nil {
+			prop.MinProps = uint64(*c.minItems)
+		}
+		if c.maxItems != nil {
+			prop.MaxProps = spec.Uint64Ptr(uint64(*c.maxItems))
+		}
+	} else {
+		if c.minItems != nil {
+			prop.MinItems = uint64(*c.minItems)
+		}
+		if c.maxItems != nil {
+			prop.MaxItems = spec.Uint64Ptr(uint64(*c.maxItems))
+		}
+	}
+	if c.unique {
+		prop.UniqueItems = true
+	}
+	if len(c.enum) > 0 {
+		dataType := ""
+		if prop.Type != nil && len(*prop.Type) > 0 {
+			dataType = (*prop.Type)[0]
+		}
+		prop.Enum = make([]interface{}, 0, len(c.enum))
+		for _, value := range c.enum {
+			prop.Enum = append(prop.Enum, stringAutoType(dataType, value))
+		}
+	}
+	if c.format != "" {
+		prop.Format = c.format
+	}
+	if c.pattern != "" {
+		prop.Pattern = c.pattern
+	}
+}
+
+// setValidateConstraints sets the constraints of the validator rules of a field on its property,
+// and those following dive on the items of the property. It reports whether the field is required.
+func setValidateConstraints(prop *spec.SchemaRef, field reflect.StructField) (required bool) {
+	rules, elemRules := validateRules(field)
+	if len(rules) == 0 && len(elemRules) == 0 {
+		return false
+	}
+	c := constraintsOf(rules, field.Type)
+	if prop.Value != nil && prop.Ref == "" {
+		c.applyTo(prop.Value, indirect(field.Type))
+	}
+
+	elemType := indirect(field.Type)
+	if len(elemRules) == 0 || prop.Value == nil {
+		return c.required
+	}
+	switch elemType.Kind() {
+	case reflect.Slice, reflect.Array:
+		if items := prop.Value.Items; items != nil && items.Ref == "" && items.Value != nil {
+			constraintsOf(elemRules, elemType.Elem()).applyTo(items.Value, indirect(elemType.Elem()))
+		}
+	case reflect.Map:
+		if values := prop.Value.AdditionalProperties.Schema; values != nil && values.Ref == "" && values.Value != nil {
+			constraintsOf(elemRules, elemType.Elem()).applyTo(values.Value, indirect(elemType.Elem()))
+		}
+	}
+	return c.required
+}
+
+// setValidateParamFrom sets the constraints of the validator rules of a field on its parameter.
+// The rules following dive constrain the items of an array parameter.
+func setValidateParamFrom(param *restful.Parameter, field reflect.StructField) {
+	rules, elemRules := validateRules(field)
+	if len(rules) == 0 && len(elemRules) == 0 {
+		return
+	}
+	c := constraintsOf(rules, field.Type)
+	if c.required {
+		param.Required(true)
+	}
+	if c.minItems != nil {
+		param.MinItems(*c.minItems)
+	}
+	if c.maxItems != nil {
+		param.MaxItems(*c.maxItems)
+	}
+	if c.unique {
+		param.UniqueItems(true)
+	}
+	if param.Data().AllowMultiple {
+		// the other constraints of an array parameter apply to its items
+		c = constraintsOf(elemRules, indirect(field.Type).Elem())
+	}
+	// restful parameters have no exclusive bounds
+	if c.minimum != nil && !c.exclusiveMin {
+		param.Minimum(*c.minimum)
+	}
+	if c.maximum != nil && !c.exclusiveMax {
+		param.Maximum(*c.maximum)
+	}
+	if c.minLength != nil {
+		param.MinLength(*c.minLength)
+	}
+	if c.maxLength != nil {
+		param.MaxLength(*c.maxLength)
+	}
+	if len(c.enum) > 0 {
+		param.PossibleValues(c.enum)
+	}
+	if c.format != "" {
+		param.DataFormat(c.format)
+	}
+	if c.pattern != "" {
+		param.Pattern(c.pattern)
+	}
+}
+
+// indirect returns the type a pointer type points to.
+func indirect(rt reflect.Type) reflect.Type {
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	return rt
+}
@@ -0,0 +1,259 @@
+package restspec
+
+import (
+	"strconv"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+const (
+	// KeyOpenAPIEncoding is a Metadata key prefix for a restful Route, followed by "." and the name of a multipart part.
+	// It is also used as the key of a restful Parameter extension.
+	KeyOpenAPIEncoding = "openapi.encoding"
+
+	// KeyOpenAPIStyle is a Metadata key prefix for a restful Route, followed by "." and the name of a parameter.
+	KeyOpenAPIStyle = "openapi.style"
+
+	// KeyOpenAPIResponseHeader is a Metadata key prefix for a restful Route, followed by "." the status code
+	// or "default", "." and the name of a response header.
+	KeyOpenAPIResponseHeader = "openapi.header"
+
+	// KeyOpenAPIStream is a Metadata key prefix for a restful Route, followed by "." and the status code
+	// or "default" of a streamed response.
+	KeyOpenAPIStream = "openapi.stream"
+
+	// KeyOpenAPIResponseContentType is a Metadata key prefix for a restful Route, followed by "." and the status code
+	// or "default" of a response whose content has other media types than those the route produces.
+	KeyOpenAPIResponseContentType = "openapi.contentType"
+
+	// KeyOpenAPICallback is a Metadata key prefix for a restful Route, followed by "." and the name of a callback.
+	KeyOpenAPICallback = "openapi.callback"
+
+	// KeyOpenAPILink is a Metadata key prefix for a restful Route, followed by "." the status code
+	// or "default", "." and the name of a response link.
+	KeyOpenAPILink = "openapi.link"
+
+	MIME_EVENT_STREAM = "text/event-stream"
+	MIME_NDJSON       = "application/x-ndjson"
+)
+
+// Header describes a response header. It extends restful.Header with the fields of OpenAPI 3.
+type Header struct {
+	restful.Header
+	// Required marks the header as always present in the response
+	Required bool
+	// Deprecated marks the header as deprecated
+	Deprecated bool
+	// Example is an example value of the header
+	Example interface{}
+}
+
+// ReturnsHeader documents a header of the response with the given status code, which must be
+// declared with Returns. Use -1 as code for the default response.
+//
+//	ws.GET("").Returns(200, "OK", []User{}).
+//		Do(restspec.ReturnsHeader(200, "X-RateLimit-Remaining", restspec.Header{
+//			Header:   restful.Header{Items: &restful.Items{Type: "integer"}},
+//			Required: true,
+//			Example:  42,
+//		}))
+func ReturnsHeader(code int, name string, header Header) func(b *restful.RouteBuilder) {
+	return func(b *restful.RouteBuilder) {
+		b.Metadata(KeyOpenAPIResponseHeader+"."+statusKey(code)+"."+name, header)
+	}
+}
+
+// ReturnsContentType documents the content of the response with the given status code in the given media types,
+// instead of those the route produces. Use -1 as code for the default response.
+//
+//	ws.GET("/{id}").Returns(404, "Not Found", Problem{}).
+//		Do(restspec.ReturnsContentType(404, "application/problem+json"))
+func ReturnsContentType(code int, mediaTypes ...string) func(b *restful.RouteBuilder) {
+	return func(b *restful.RouteBuilder) {
+		b.Metadata(KeyOpenAPIResponseContentType+"."+statusKey(code), mediaTypes)
+	}
+}
+
+// Event describes one kind of event of a text/event-stream response.
+type Event struct {
+	// Name is the value of the event field, empty for unnamed events
+	Name string
+	// Description of the event
+	Description string
+	// Data is a sample of the data field, documented like the model of Returns
+	Data interface{}
+	// ID documents the id field of the event
+	ID bool
+	// Retry documents the retry field of the event
+	Retry bool
+}
+
+// Stream describes a response which is sent as a sequence of items.
+type Stream struct {
+	// MediaType of the response, e.g. text/event-stream or application/x-ndjson, application/octet-stream if empty
+	MediaType string
+	// Events are the kinds of events of a text/event-stream response
+	Events []Event
+	// Item is a sample of a single item, e.g. a line of an application/x-ndjson response or a record of text/csv.
+	// Without an Item the response is documented as a chunked binary download, or any JSON documents for NDJSON.
+	Item interface{}
+}
+
+// ReturnsStream documents a streamed response with the given status code. Use -1 as code for the default response.
+//
+//	ws.GET("/export").Do(restspec.ReturnsStream(200, "OK", restspec.Stream{MediaType: "text/csv"}))
+func ReturnsStream(code int, message string, stream Stream) func(b *restful.RouteBuilder) {
+	return func(b *restful.RouteBuilder) {
+		if code > 0 {
+			b.Returns(code, message, nil)
+		} else {
+			b.DefaultReturns(message, nil)
+		}
+		b.Metadata(KeyOpenAPIStream+"."+statusKey(code), stream)
+	}
+}
+
+// ReturnsEventStream documents a text/event-stream response with the given kinds of server-sent events.
+//
+//	ws.GET("/events").Do(restspec.ReturnsEventStream(200, "OK",
+//		restspec.Event{Name: "created", Data: User{}, ID: true},
+//		restspec.Event{Name: "deleted", Data: ""}))
+func ReturnsEventStream(code int, message string, events ...Event) func(b *restful.RouteBuilder) {
+	return ReturnsStream(code, message, Stream{MediaType: MIME_EVENT_STREAM, Events: events})
+}
+
+// ReturnsNDJSON documents an application/x-ndjson response, where each line is a JSON document like item.
+func ReturnsNDJSON(code int, message string, item interface{}) func(b *restful.RouteBuilder) {
+	return ReturnsStream(code, message, Stream{MediaType: MIME_NDJSON, Item: item})
+}
+
+// Download is a marker model for Returns documenting a binary file download.
+//
+//	ws.GET("/{id}/report").Returns(200, "OK", restspec.Download{
+//		MediaType:   "application/pdf",
+//		Disposition: `attachment; filename="report.pdf"`,
+//		Ranges:      true,
+//	})
+type Download struct {
+	// MediaType of the file, application/octet-stream if empty
+	MediaType string
+	// Disposition is an example of the Content-Disposition header, which is documented if set
+	Disposition string
+	// Ranges documents support for range requests, with 206 and 416 responses
+	Ranges bool
+}
+
+// Callback describes a request the API sends to a URL registered by the client, e.g. a webhook.
+type Callback struct {
+	// Expression is the runtime expression of the callback URL, e.g. {$request.body#/callbackUrl}
+	Expression string
+	// Method of the callback request, POST if empty
+	Method string
+	// Doc is the summary of the callback request
+	Doc string
+	// Reads is a sample of the request body sent to the callback, documented like the model of Reads
+	Reads interface{}
+	// MediaType of the request body, application/json if empty
+	MediaType string
+	// Returns documents the responses expected from the callback URL
+	Returns []restful.ResponseError
+}
+
+// Callbacks documents a named callback of the operation.
+//
+//	ws.POST("/subscriptions").Do(restspec.Callbacks("onEvent", restspec.Callback{
+//		Expression: "{$request.body#/callbackUrl}",
+//		Reads:      Event{},
+//		Returns:    []restful.ResponseError{{Code: 204, Message: "event received"}},
+//	}))
+func Callbacks(name string, callback Callback) func(b *restful.RouteBuilder) {
+	return func(b *restful.RouteBuilder) {
+		b.Metadata(KeyOpenAPICallback+"."+name, callback)
+	}
+}
+
+// Link describes how a value of a response can be used as parameter of another operation.
+type Link struct {
+	// OperationID of the linked operation, as set by restful.RouteBuilder.Operation
+	OperationID string
+	// Parameters maps parameter names of the linked operation to values or runtime expressions,
+	// e.g. "id": "$response.body#/id"
+	Parameters map[string]interface{}
+	// Description of the link
+	Description string
+}
+
+// ResponseLink documents a link of the response with the given status code, which must be
+// declared with Returns. Use -1 as code for the default response.
+//
+//	ws.POST("").Operation("createUser").Returns(201, "Created", User{}).
+//		Do(restspec.ResponseLink(201, "GetUser", restspec.Link{
+//			OperationID: "getUser",
+//			Parameters:  map[string]interface{}{"id": "$response.body#/id"},
+//		}))
+func ResponseLink(code int, name string, link Link) func(b *restful.RouteBuilder) {
+	return func(b *restful.RouteBuilder) {
+		b.Metadata(KeyOpenAPILink+"."+statusKey(code)+"."+name, link)
+	}
+}
+
+// statusKey returns the key of a response in Metadata keys.
+func statusKey(code int) string {
+	if code > 0 {
+		return strconv.Itoa(code)
+	}
+	return "default"
+}
+
+// Encoding describes how a single part of a multipart/form-data request body is encoded.
+type Encoding struct {
+	// ContentType lists the allowed content types of the part, e.g. "image/png, image/jpeg"
+	ContentType string
+	// Headers documents additional headers of the part
+	Headers map[string]restful.Header
+	// Required marks the part as required
+	Required bool
+}
+
+// PartEncoding documents the encoding of the multipart part with the given name.
+// It overrides the encoding taken from the `in` tag of a ReadSample.
+//
+//	ws.POST("/{id}/cover").Do(restspec.PartEncoding("cover", restspec.Encoding{ContentType: "image/png"}))
+func PartEncoding(part string, encoding Encoding) func(b *restful.RouteBuilder) {
+	return func(b *restful.RouteBuilder) {
+		b.Metadata(KeyOpenAPIEncoding+"."+part, encoding)
+	}
+}
+
+// Style describes how the value of a query, header, path or cookie parameter is serialized.
+type Style struct {
+	// Style is one of form, simple, label, matrix, spaceDelimited, pipeDelimited or deepObject
+	Style string
+	// Explode generates separate parameters for each value of an array or object if set to true
+	Explode *bool
+	// AllowReserved allows reserved characters of RFC3986 without percent-encoding in query parameters
+	AllowReserved bool
+}
+
+// ParamStyle sets the serialization style of the parameter with the given name.
+// It overrides the style derived from the collection format of the parameter.
+//
+//	explode := false
+//	ws.GET("").Do(restspec.ParamStyle("ids", restspec.Style{Style: "form", Explode: &explode}))
+func ParamStyle(param string, style Style) func(b *restful.RouteBuilder) {
+	return func(b *restful.RouteBuilder) {
+		b.Metadata(KeyOpenAPIStyle+"."+param, style)
+	}
+}
+
+// encodingOf returns the encoding of a form parameter declared on the parameter or by PartEncoding.
+func encodingOf(r restful.Route, param restful.ParameterData) *Encoding {
+	var encoding *Encoding
+	if e, ok := param.Extensions[KeyOpenAPIEncoding].(Encoding); ok {
+		encoding = &e
+	}
+	if e, ok := r.Metadata[KeyOpenAPIEncoding+"."+param.Name].(Encoding); ok {
+		encoding = &e
+	}
+	return encoding
+}
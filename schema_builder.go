@@ -1,6 +1,8 @@
 package restspec
 
 import (
+	"fmt"
+	"log"
 	"reflect"
 	"strings"
 
@@ -16,6 +18,8 @@ const (
 type schemaBuilder struct {
 	Schemas *spec.Schemas
 	Config  Config
+	// Names of the components of types, see collectNames
+	Names *componentNames
 }
 
 // Documented is
@@ -48,17 +52,19 @@ func (b *schemaBuilder) addModel(st reflect.Type, nameOverride string) *spec.Sch
 	if st.Kind() == reflect.Ptr {
 		st = st.Elem()
 	}
-	if b.isSliceOrArrayType(st.Kind()) {
+	if _, ok := b.ownSchema(st); !ok && b.isSliceOrArrayType(st.Kind()) {
 		st = st.Elem()
 	}
 
 	stName := st.String()
-	modelName := keyFrom(st, b.Config)
+	modelName := keyFrom(st, b.Config, b.Names)
 	if nameOverride != "" {
 		modelName = nameOverride
 	}
-	// no models needed for primitive types unless it has alias
-	if b.isPrimitiveType(modelName, st.Kind()) {
+	enumValues, isEnum := b.enumValues(st)
+	ownSchema, hasOwnSchema := b.ownSchema(st)
+	// no models needed for primitive types unless it has alias, is an enum or has its own schema
+	if b.isPrimitiveType(modelName, st.Kind()) && !isEnum && !hasOwnSchema {
 		if nameOverride == "" {
 			return nil
 		}
@@ -67,13 +73,23 @@ func (b *schemaBuilder) addModel(st reflect.Type, nameOverride string) *spec.Sch
 	// JSON arrays, except that []byte encodes as a base64-encoded string.
 	// If we see a []byte here, treat it at as a primitive type (string)
 	// and deal with it in buildArrayTypeProperty.
-	if b.isByteArrayType(st) {
+	if b.isByteArrayType(st) && !hasOwnSchema {
 		return nil
 	}
 	// see if we already have visited this model
 	if _, err := b.Schemas.JSONLookup(modelName); err == nil {
 		return nil
 	}
+	// a type with its own schema replaces the one built by reflection, see SchemaProvider
+	if hasOwnSchema {
+		own := *ownSchema
+		if own.Description == "" {
+			own.Description = docOfType(st)
+		}
+		sm := spec.SchemaRef{Value: &own}
+		(*b.Schemas)[modelName] = &sm
+		return &sm
+	}
 	sm := spec.SchemaRef{
 		Value: &spec.Schema{
 			Required:   []string{},
@@ -83,7 +99,7 @@ func (b *schemaBuilder) addModel(st reflect.Type, nameOverride string) *spec.Sch
 	}
 
 	alias, _, _ := strings.Cut(stName, ".")
-	if alias != "" && alias != "restspec" {
+	if alias != "" && alias != "restspec" && st.Name() != "" {
 		sm.Value.Extensions[ExGoType] = stName
 		sm.Value.Extensions[ExGoTypeImport] = map[string]string{
 			"path": st.PkgPath(),
@@ -93,6 +109,10 @@ func (b *schemaBuilder) addModel(st reflect.Type, nameOverride string) *spec.Sch
 	// reference the model before further initializing (enables recursive structs)
 	(*b.Schemas)[modelName] = &sm
 
+	if isEnum {
+		b.setEnum(sm.Value, st, enumValues)
+		return &sm
+	}
 	if st.Kind() == reflect.Map {
 		_, sm = b.buildMapType(st, "value", modelName)
 		(*b.Schemas)[modelName] = &sm
@@ -105,6 +125,7 @@ func (b *schemaBuilder) addModel(st reflect.Type, nameOverride string) *spec.Sch
 
 	fullDoc := getDocFromMethodSwaggerDoc2(st)
 	modelDescriptions := []string{}
+	embeddedDocs := []string{}
 
 	for i := 0; i < st.NumField(); i++ {
 		field := st.Field(i)
@@ -113,26 +134,52 @@ func (b *schemaBuilder) addModel(st reflect.Type, nameOverride string) *spec.Sch
 			modelDescriptions = append(modelDescriptions, modelDescription)
 		}
 
+		// the doc comment of an embedded struct describes its component of allOf, or else the model
+		if len(jsonName) == 0 && isEmbedded(field) && b.jsonNameOfField(field) != "" {
+			if doc := docOfField(st, field); doc != "" && b.embedAsAllOf(field) {
+				last := len(sm.Value.AllOf) - 1
+				sm.Value.AllOf[last] = withConstraints(sm.Value.AllOf[last], &spec.Schema{Description: doc})
+			} else if !b.embedAsAllOf(field) {
+				embeddedDocs = append(embeddedDocs, b.flattenedDocs(st, field)...)
+			}
+		}
+
 		// add if not omitted
 		if len(jsonName) != 0 {
-			// update description
+			// update description, else by the doc comment of the field
 			if fieldDoc, ok := fullDoc[jsonName]; ok {
 				prop.Value.Description = fieldDoc
+			} else if prop.Value.Description == "" {
+				prop.Value.Description = docOfField(st, field)
+			}
+			// update Required, also by the rules of a validator
+			required := b.isPropertyRequired(field)
+			if setValidateConstraints(&prop, field) {
+				required = true
 			}
-			// update Required
-			if b.isPropertyRequired(field) {
+			b.Config.Nullability.setNullable(&prop, field)
+			if prop.Ref != "" {
+				// a reference has no siblings, so the description, default and constraints of the field go next to allOf
+				prop = *withConstraints(&spec.SchemaRef{Ref: prop.Ref, Value: spec.NewSchema()}, prop.Value)
+			}
+			if required {
 				sm.Value.Required = append(sm.Value.Required, jsonName)
 			}
 			sm.Value.Properties[jsonName] = &prop
 		}
 	}
+	composeAllOf(sm.Value)
 
 	// We always overwrite documentation if SwaggerDoc method exists
 	// "" is special for documenting the struct itself
 	if modelDoc, ok := fullDoc[""]; ok {
 		sm.Value.Description = modelDoc
-	} else if len(modelDescriptions) != 0 {
-		sm.Value.Description = strings.Join(modelDescriptions, "\n")
+	} else {
+		if doc := docOfType(st); len(modelDescriptions) == 0 && doc != "" {
+			modelDescriptions = append(modelDescriptions, doc)
+		}
+		// followed by the doc comments of the flattened embedded structs
+		sm.Value.Description = strings.Join(append(modelDescriptions, embeddedDocs...), "\n")
 	}
 
 	// Call handler to update sch
@@ -146,6 +193,19 @@ func (b *schemaBuilder) addModel(st reflect.Type, nameOverride string) *spec.Sch
 	return &sm
 }
 
+// reportTagError reports a struct tag which can not be parsed to the TagErrorHandler of the config,
+// or logs it if there is none.
+func reportTagError(cfg Config, err error) {
+	if cfg.TagErrorHandler != nil {
+		cfg.TagErrorHandler(err)
+		return
+	}
+	log.Printf("restspec: %v", err)
+}
+
+// IgnoreTagErrors is a TagErrorHandler which silences the errors of struct tags.
+func IgnoreTagErrors(error) {}
+
 func (b *schemaBuilder) isPropertyRequired(field reflect.StructField) bool {
 	required := true
 	if optionalTag := field.Tag.Get("optional"); optionalTag == "true" {
@@ -176,26 +236,22 @@ func (b *schemaBuilder) buildProperty(field reflect.StructField, model *spec.Sch
 		modelDescription = tag
 	}
 
+	// document a sql.Null*-like field as its value, see Nullability
+	if value, ok := nullWrapperValue(field.Type); ok && b.Config.Nullability.Wrappers {
+		field.Type = value.Type
+		_, _, prop = b.buildProperty(field, model, modelName)
+		return jsonName, modelDescription, prop
+	}
+
 	prop.Value = &spec.Schema{}
-	setPropertyMetadata(prop.Value, field)
+	if err := setPropertyMetadata(prop.Value, field); err != nil {
+		reportTagError(b.Config, fmt.Errorf("model %s: %w", modelName, err))
+	}
 	if prop.Value.Type != nil {
 		return jsonName, modelDescription, prop
 	}
 	fieldType := field.Type
 
-	// check if type is doing its own marshalling
-	// marshalerType := reflect.TypeOf((*json.Marshaler)(nil)).Elem()
-	// if fieldType.Implements(marshalerType) {
-	// 	var pType = "string"
-	// 	if prop.Type == nil {
-	// 		prop.Type = []string{pType}
-	// 	}
-	// 	if prop.Format == "" {
-	// 		prop.Format = b.jsonSchemaFormat(keyFrom(fieldType, b.Config), fieldType.Kind())
-	// 	}
-	// 	return jsonName, modelDescription, prop
-	// }
-
 	// check if annotation says it is a string
 	if jsonTag := field.Tag.Get("json"); jsonTag != "" {
 		s := strings.Split(jsonTag, ",")
@@ -208,8 +264,21 @@ func (b *schemaBuilder) buildProperty(field reflect.StructField, model *spec.Sch
 
 	fieldKind := fieldType.Kind()
 
+	// check for enums and types with their own schema before primitives, see Enum and SchemaProvider.
+	// Types doing their own marshalling have their own schema too, see marshalerSchema.
+	if fieldKind != reflect.Ptr {
+		if ref, ok := b.componentRef(fieldType); ok {
+			prop.Ref = ref
+			return jsonName, modelDescription, prop
+		}
+	}
+
+	if b.setPolymorph(prop.Value, fieldType) {
+		return jsonName, modelDescription, prop
+	}
+
 	// check for primitive first
-	fieldTypeName := keyFrom(fieldType, b.Config)
+	fieldTypeName := keyFrom(fieldType, b.Config, b.Names)
 	if b.isPrimitiveType(fieldTypeName, fieldKind) {
 		mapped := b.jsonSchemaType(fieldTypeName, fieldKind)
 		prop.Value.Type = &spec.Types{mapped}
@@ -220,7 +289,7 @@ func (b *schemaBuilder) buildProperty(field reflect.StructField, model *spec.Sch
 	// not a primitive
 	switch {
 	case fieldKind == reflect.Struct:
-		jsonName, prop := b.buildStructTypeProperty(field, jsonName, model)
+		jsonName, prop := b.buildStructTypeProperty(field, jsonName, modelName, model)
 		return jsonName, modelDescription, prop
 	case b.isSliceOrArrayType(fieldKind):
 		jsonName, prop := b.buildArrayTypeProperty(field, jsonName, modelName)
@@ -233,12 +302,11 @@ func (b *schemaBuilder) buildProperty(field reflect.StructField, model *spec.Sch
 		return jsonName, modelDescription, prop
 	}
 
-	modelType := keyFrom(fieldType, b.Config)
+	modelType := keyFrom(fieldType, b.Config, b.Names)
 	prop.Ref = componentRoot + modelType
 
-	if fieldType.Name() == "" { // override type of anonymous structs
-		// FIXME: Still need a way to handle anonymous struct model naming.
-		nestedTypeName := modelName + "." + jsonName
+	if fieldType.Name() == "" { // override type of anonymous types
+		nestedTypeName := b.anonymousName(modelName, jsonName, fieldType)
 		prop.Ref = componentRoot + nestedTypeName
 		b.addModel(fieldType, nestedTypeName)
 	}
@@ -258,33 +326,44 @@ func hasNamedJSONTag(field reflect.StructField) bool {
 	return len(parts[0]) > 0
 }
 
-func (b *schemaBuilder) buildStructTypeProperty(field reflect.StructField, jsonName string, model *spec.Schema) (nameJson string, prop spec.SchemaRef) {
+func (b *schemaBuilder) buildStructTypeProperty(field reflect.StructField, jsonName, modelName string, model *spec.Schema) (nameJson string, prop spec.SchemaRef) {
 	prop.Value = &spec.Schema{}
-	setPropertyMetadata(prop.Value, field)
+	_ = setPropertyMetadata(prop.Value, field) // reported by buildProperty
 	fieldType := field.Type
 	// check for anonymous
 	if len(fieldType.Name()) == 0 {
-		// anonymous
-		// FIXME: Still need a way to handle anonymous struct model naming.
-		//anonType := model.ID + "." + jsonName
-		anonType := jsonName
+		anonType := b.anonymousName(modelName, jsonName, fieldType)
 		b.addModel(fieldType, anonType)
 		prop.Ref = componentRoot + anonType
+		b.inlineAnonymous(&prop, fieldType, anonType)
 		return jsonName, prop
 	}
 
-	if field.Name == fieldType.Name() && field.Anonymous && !hasNamedJSONTag(field) {
+	if isEmbedded(field) && b.embedAsAllOf(field) {
+		// embedded struct as a component of allOf, see composeAllOf
+		b.addModel(fieldType, "")
+		model.AllOf = append(model.AllOf, &spec.SchemaRef{
+			Ref:   componentRoot + keyFrom(fieldType, b.Config, b.Names),
+			Value: spec.NewSchema(),
+		})
+		// empty name signals skip property
+		return "", prop
+	}
+
+	if isEmbedded(field) {
 		schemas := spec.Schemas{}
 		for k, v := range *b.Schemas {
 			schemas[k] = v
 		}
 
 		// embedded struct
-		sub := schemaBuilder{Schemas: &schemas, Config: b.Config}
+		sub := schemaBuilder{Schemas: &schemas, Config: b.Config, Names: b.Names}
 		sub.addModel(fieldType, "")
-		subKey := keyFrom(fieldType, b.Config)
+		subKey := keyFrom(fieldType, b.Config, b.Names)
 		// merge properties from sub
 		subModel, _ := (*sub.Schemas)[subKey]
+		// keep the components of allOf of the embedded struct itself
+		model.AllOf = append(model.AllOf, subModel.Value.AllOf...)
 		for k, v := range subModel.Value.Properties {
 			model.Properties[k] = v
 			// if subModel says this property is required then include it
@@ -312,14 +391,59 @@ func (b *schemaBuilder) buildStructTypeProperty(field reflect.StructField, jsonN
 	}
 	// simple struct
 	b.addModel(fieldType, "")
-	var pType = keyFrom(fieldType, b.Config)
+	var pType = keyFrom(fieldType, b.Config, b.Names)
 	prop.Ref = componentRoot + pType
 	return jsonName, prop
 }
 
+// isEmbedded reports whether a field is an embedded struct, whose properties are those of the model.
+func isEmbedded(field reflect.StructField) bool {
+	return field.Anonymous && field.Type.Kind() == reflect.Struct && field.Name == field.Type.Name() && !hasNamedJSONTag(field)
+}
+
+// flattenedDocs returns the doc comments of a flattened embedded struct and of the structs it flattens in turn,
+// which describe the model their properties are flattened into.
+func (b *schemaBuilder) flattenedDocs(st reflect.Type, field reflect.StructField) (docs []string) {
+	if doc := docOfField(st, field); doc != "" {
+		docs = append(docs, doc)
+	}
+	for i := 0; i < field.Type.NumField(); i++ {
+		if embedded := field.Type.Field(i); isEmbedded(embedded) && b.jsonNameOfField(embedded) != "" && !b.embedAsAllOf(embedded) {
+			docs = append(docs, b.flattenedDocs(field.Type, embedded)...)
+		}
+	}
+	return docs
+}
+
+// embedAsAllOf reports whether an embedded struct is documented as a component of allOf instead of
+// flattening its properties, by the embed tag of the field, "allOf" or "flatten", or else by the config.
+func (b *schemaBuilder) embedAsAllOf(field reflect.StructField) bool {
+	switch field.Tag.Get("embed") {
+	case "allOf":
+		return true
+	case "flatten":
+		return false
+	}
+	return b.Config.EmbedAsAllOf
+}
+
+// composeAllOf moves the own properties of a model with embedded structs in allOf into the last
+// schema of allOf, e.g. allOf: [{$ref: Base}, {properties of the model}].
+func composeAllOf(model *spec.Schema) {
+	if len(model.AllOf) == 0 {
+		return
+	}
+	if len(model.Properties) > 0 {
+		own := &spec.Schema{Properties: model.Properties, Required: model.Required}
+		model.AllOf = append(model.AllOf, &spec.SchemaRef{Value: own})
+	}
+	model.Properties = nil
+	model.Required = nil
+}
+
 func (b *schemaBuilder) buildArrayTypeProperty(field reflect.StructField, jsonName, modelName string) (nameJson string, prop spec.SchemaRef) {
 	prop.Value = &spec.Schema{}
-	setPropertyMetadata(prop.Value, field)
+	_ = setPropertyMetadata(prop.Value, field) // reported by buildProperty
 	fieldType := field.Type
 	if fieldType.Elem().Kind() == reflect.Uint8 {
 		stringt := "string"
@@ -334,6 +458,11 @@ func (b *schemaBuilder) buildArrayTypeProperty(field reflect.StructField, jsonNa
 	isArray := b.isSliceOrArrayType(fieldType.Kind())
 	for isArray {
 		itemType = itemType.Elem()
+		if ref, ok := b.componentRef(itemType); ok {
+			itemSchema.Value.Type = &spec.Types{"array"}
+			itemSchema.Value.Items = &spec.SchemaRef{Ref: ref, Value: spec.NewSchema()}
+			return jsonName, prop
+		}
 		isArray = b.isSliceOrArrayType(itemType.Kind())
 		if itemType.Kind() == reflect.Uint8 {
 			stringt := "string"
@@ -347,6 +476,9 @@ func (b *schemaBuilder) buildArrayTypeProperty(field reflect.StructField, jsonNa
 		itemSchema.Value.Type = &spec.Types{"array"}
 		itemSchema = itemSchema.Value.Items
 	}
+	if b.setPolymorph(itemSchema.Value, itemType) {
+		return jsonName, prop
+	}
 	isPrimitive := b.isPrimitiveType(itemType.Name(), itemType.Kind())
 	elemTypeName := b.getElementTypeName(modelName, jsonName, itemType)
 	if isPrimitive {
@@ -362,13 +494,14 @@ func (b *schemaBuilder) buildArrayTypeProperty(field reflect.StructField, jsonNa
 	}
 	if !isPrimitive {
 		b.addModel(itemType, elemTypeName)
+		b.inlineAnonymous(itemSchema, itemType, elemTypeName)
 	}
 	return jsonName, prop
 }
 
 func (b *schemaBuilder) buildMapTypeProperty(field reflect.StructField, jsonName, modelName string) (nameJson string, prop spec.SchemaRef) {
 	nameJson, prop = b.buildMapType(field.Type, jsonName, modelName)
-	setPropertyMetadata(prop.Value, field)
+	_ = setPropertyMetadata(prop.Value, field) // reported by buildProperty
 	return nameJson, prop
 }
 
@@ -376,6 +509,22 @@ func (b *schemaBuilder) buildMapType(mapType reflect.Type, jsonName, modelName s
 	var pType = "object"
 	prop.Value = &spec.Schema{}
 	prop.Value.Type = &spec.Types{pType}
+	if keys := b.keySchema(mapType); keys != nil {
+		initPropExtensions(&prop.Value.Extensions)
+		prop.Value.Extensions[ExPropertyNames] = keys
+	}
+
+	// An interface with registered implementations is one of them, see Polymorph
+	values := &spec.Schema{}
+	if b.setPolymorph(values, mapType.Elem()) {
+		prop.Value.AdditionalProperties = spec.AdditionalProperties{Schema: &spec.SchemaRef{Value: values}}
+		return jsonName, prop
+	}
+
+	if ref, ok := b.componentRef(mapType.Elem()); ok {
+		prop.Value.AdditionalProperties = spec.AdditionalProperties{Schema: &spec.SchemaRef{Ref: ref, Value: spec.NewSchema()}}
+		return jsonName, prop
+	}
 
 	// As long as the element isn't an interface, we should be able to figure out what the
 	// intended type is and represent it in `AdditionalProperties`.
@@ -392,6 +541,12 @@ func (b *schemaBuilder) buildMapType(mapType reflect.Type, jsonName, modelName s
 				Value: &spec.Schema{},
 			},
 		}
+		if ref, ok := b.componentRef(mapType.Elem()); ok && isSlice {
+			array := spec.NewArraySchema()
+			array.Items = &spec.SchemaRef{Ref: ref, Value: spec.NewSchema()}
+			prop.Value.AdditionalProperties.Schema.Value = array
+			return jsonName, prop
+		}
 		// golang encoding/json packages says array and slice values encode as
 		// JSON arrays, except that []byte encodes as a base64-encoded string.
 		// If we see a []byte here, treat it at as a string
@@ -422,16 +577,25 @@ func (b *schemaBuilder) buildMapType(mapType reflect.Type, jsonName, modelName s
 			}
 			if !isPrimitive {
 				b.addModel(mapType.Elem(), elemTypeName)
+				values := prop.Value.AdditionalProperties.Schema
+				if isSlice {
+					values = values.Value.Items
+				}
+				b.inlineAnonymous(values, mapType.Elem(), elemTypeName)
 			}
 		}
 	}
 	return jsonName, prop
 }
 func (b *schemaBuilder) buildPointerTypeProperty(field reflect.StructField, jsonName, modelName string) (nameJson string, prop spec.SchemaRef) {
-	setPropertyMetadata(prop.Value, field)
 	fieldType := field.Type
 
 	prop.Value = &spec.Schema{}
+	_ = setPropertyMetadata(prop.Value, field) // reported by buildProperty
+	if ref, ok := b.componentRef(fieldType.Elem()); ok {
+		prop.Ref = ref
+		return jsonName, prop
+	}
 	// override type of pointer to list-likes
 	if fieldType.Elem().Kind() == reflect.Slice || fieldType.Elem().Kind() == reflect.Array {
 		var pType = "array"
@@ -441,6 +605,10 @@ func (b *schemaBuilder) buildPointerTypeProperty(field reflect.StructField, json
 		prop.Value.Items = &spec.SchemaRef{
 			Value: &spec.Schema{},
 		}
+		if ref, ok := b.componentRef(fieldType.Elem().Elem()); ok {
+			prop.Value.Items.Ref = ref
+			return jsonName, prop
+		}
 		if isPrimitive {
 			primName := b.jsonSchemaType(elemName, fieldType.Elem().Elem().Kind())
 			prop.Value.Items.Value.Type = &spec.Types{primName}
@@ -450,10 +618,11 @@ func (b *schemaBuilder) buildPointerTypeProperty(field reflect.StructField, json
 		if !isPrimitive {
 			// add|overwrite model for element type
 			b.addModel(fieldType.Elem().Elem(), elemName)
+			b.inlineAnonymous(prop.Value.Items, fieldType.Elem().Elem(), elemName)
 		}
 	} else {
 		// non-array, pointer type
-		fieldTypeName := keyFrom(fieldType.Elem(), b.Config)
+		fieldTypeName := keyFrom(fieldType.Elem(), b.Config, b.Names)
 		isPrimitive := b.isPrimitiveType(fieldTypeName, fieldType.Elem().Kind())
 		var pType = b.jsonSchemaType(fieldTypeName, fieldType.Elem().Kind()) // no star, include pkg path
 		if isPrimitive {
@@ -464,11 +633,12 @@ func (b *schemaBuilder) buildPointerTypeProperty(field reflect.StructField, json
 		prop.Ref = componentRoot + pType
 		elemName := ""
 		if fieldType.Elem().Name() == "" {
-			elemName = modelName + "." + jsonName
+			elemName = b.anonymousName(modelName, jsonName, fieldType.Elem())
 			prop.Ref = componentRoot + elemName
 		}
 		if !isPrimitive {
 			b.addModel(fieldType.Elem(), elemName)
+			b.inlineAnonymous(&prop, fieldType.Elem(), elemName)
 		}
 	}
 	return jsonName, prop
@@ -479,25 +649,59 @@ func (b *schemaBuilder) getElementTypeName(modelName, jsonName string, t reflect
 		t = t.Elem()
 	}
 	if t.Name() == "" {
-		return modelName + "." + jsonName
+		return b.anonymousName(modelName, jsonName, t)
 	}
-	return keyFrom(t, b.Config)
+	return keyFrom(t, b.Config, b.Names)
 }
 
-func keyFrom(st reflect.Type, cfg Config) string {
-	key := st.String()
+// anonymousName returns the name of the component of the anonymous type of a field, by the
+// AnonymousTypeNameHandler of the config or else of the model and field, e.g. restspec.Order.meta.
+// Names wanted by several types get a suffix in order of the types, see componentNames.
+func (b *schemaBuilder) anonymousName(modelName, jsonName string, t reflect.Type) string {
+	name := modelName + "." + jsonName
+	if b.Config.AnonymousTypeNameHandler != nil {
+		name = b.Config.AnonymousTypeNameHandler(modelName, jsonName)
+	}
+	return b.Names.anonymousName(sanitizeName(name), t)
+}
+
+// inlineAnonymous replaces the reference to the component of an anonymous struct by the schema
+// of the component, which is removed, if the config inlines anonymous structs.
+func (b *schemaBuilder) inlineAnonymous(schema *spec.SchemaRef, t reflect.Type, name string) {
+	t = indirect(t)
+	if schema == nil || !b.Config.InlineAnonymousStructs || t.Name() != "" || t.Kind() != reflect.Struct {
+		return
+	}
+	sm, ok := (*b.Schemas)[name]
+	if !ok || sm.Value == nil {
+		return
+	}
+	delete(*b.Schemas, name)
+	inline := *sm.Value
+	inline.Type = &spec.Types{"object"}
+	if schema.Value != nil && schema.Value.Description != "" {
+		inline.Description = schema.Value.Description
+	}
+	schema.Ref, schema.Value = "", &inline
+}
+
+// keyFrom returns the name of the component of a type, e.g. restspec.Sample, see componentName.
+// The ModelTypeNameHandler of the config is the final override.
+func keyFrom(st reflect.Type, cfg Config, names *componentNames) string {
 	if cfg.ModelTypeNameHandler != nil {
 		if name, ok := cfg.ModelTypeNameHandler(st); ok {
-			key = name
+			return name
 		}
 	}
-	if len(st.Name()) == 0 { // unnamed type
-		// If it is an array, remove the leading []
-		key = strings.TrimPrefix(key, "[]")
-		// Swagger UI has special meaning for [
-		key = strings.Replace(key, "[]", "||", -1)
+	if st.Name() == "" {
+		switch st.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array:
+			// the name of its elements
+			return keyFrom(st.Elem(), cfg, names)
+		}
+		return sanitizeName(readableName(st.String()))
 	}
-	return key
+	return names.typeName(st, componentName(st))
 }
 
 func (b *schemaBuilder) isSliceOrArrayType(t reflect.Kind) bool {
@@ -1,11 +1,19 @@
 package restspec
 
 import (
+	"bytes"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"log"
+	"net"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/emicklei/go-restful/v3"
 	spec "github.com/getkin/kin-openapi/openapi3"
 )
 
@@ -275,7 +283,7 @@ func TestReturningStringToStringDictionary(t *testing.T) {
 		t.Errorf("got %v want %v", got, want)
 	}
 
-	schema, schemaFound := (*db.Schemas)["map[string]string"]
+	schema, schemaFound := (*db.Schemas)["MapOfStringToString"]
 	if !schemaFound {
 		t.Errorf("could not find schema")
 	} else {
@@ -296,7 +304,7 @@ func TestReturningStringToSliceObjectDictionary(t *testing.T) {
 	if got, want := len(*db.Schemas), 2; got != want {
 		t.Errorf("got %v want %v", got, want)
 	}
-	schema, schemaFound := (*db.Schemas)["map[string]||restspec.DictionaryValue"]
+	schema, schemaFound := (*db.Schemas)["MapOfStringToDictionaryValueList"]
 	if !schemaFound {
 		t.Errorf("could not find schema")
 	} else {
@@ -574,3 +582,388 @@ func TestTimeField(t *testing.T) {
 		t.Errorf("got [%v:%T] want [%v:%T]", got, got, want, want)
 	}
 }
+
+type validatedAccount struct {
+	Name     string            `json:"name,omitempty" validate:"required,min=1,max=64"`
+	Email    string            `json:"email" binding:"email"`
+	ID       string            `json:"id" validate:"uuid4"`
+	Role     string            `json:"role" validate:"oneof=admin 'power user' guest"`
+	Level    int               `json:"level" validate:"gt=0,lte=10,oneof=1 5 10"`
+	Score    float64           `json:"score" validate:"gt=0"`
+	Tags     []string          `json:"tags" validate:"min=1,max=5,unique,dive,alphanum,max=16"`
+	Labels   map[string]string `json:"labels" validate:"max=3,dive,keys,min=2,endkeys,required,max=8"`
+	Nickname *string           `json:"nickname" validate:"omitempty,startswith=@"`
+	Code     string            `json:"code" validate:"len=6|eq=0"`
+}
+
+func TestValidateTags(t *testing.T) {
+	db := schemaBuilder{Schemas: &spec.Schemas{}, Config: Config{}}
+	db.addModelFrom(validatedAccount{})
+	sc := (*db.Schemas)["restspec.validatedAccount"].Value
+	t.Log(asJSON(sc))
+
+	if got, want := sc.Required[0], "name"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	name := sc.Properties["name"].Value
+	if name.MinLength != 1 || name.MaxLength == nil || *name.MaxLength != 64 {
+		t.Errorf("unexpected length of %v", asJSON(name))
+	}
+	if got, want := sc.Properties["email"].Value.Format, "email"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := sc.Properties["id"].Value.Format, "uuid"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := asJSON(sc.Properties["role"].Value.Enum), asJSON([]interface{}{"admin", "power user", "guest"}); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	level := sc.Properties["level"].Value
+	if *level.Min != 1 || level.ExclusiveMin || *level.Max != 10 || asJSON(level.Enum) != asJSON([]interface{}{1, 5, 10}) {
+		t.Errorf("unexpected level %v", asJSON(level))
+	}
+	if score := sc.Properties["score"].Value; *score.Min != 0 || !score.ExclusiveMin {
+		t.Errorf("unexpected score %v", asJSON(score))
+	}
+	tags := sc.Properties["tags"].Value
+	if tags.MinItems != 1 || *tags.MaxItems != 5 || !tags.UniqueItems {
+		t.Errorf("unexpected tags %v", asJSON(tags))
+	}
+	if items := tags.Items.Value; items.Pattern != `^[a-zA-Z0-9]+$` || *items.MaxLength != 16 {
+		t.Errorf("unexpected tag items %v", asJSON(items))
+	}
+	labels := sc.Properties["labels"].Value
+	if *labels.MaxProps != 3 || *labels.AdditionalProperties.Schema.Value.MaxLength != 8 {
+		t.Errorf("unexpected labels %v", asJSON(labels))
+	}
+	if got, want := sc.Properties["nickname"].Value.Pattern, "^@"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if code := sc.Properties["code"].Value; code.MinLength != 0 || code.MaxLength != nil {
+		t.Errorf("unexpected code %v", asJSON(code))
+	}
+}
+
+type taggedReading struct {
+	Value    float64            `json:"value" exclusiveMinimum:"0" exclusiveMaximum:"true" maximum:"100" multipleOf:"0.5" title:"Reading"`
+	Unit     string             `json:"unit" pattern:"^[a-z]+$" minLength:"1" maxLength:"8" default:"celsius" enum:"celsius|kelvin"`
+	Samples  []int              `json:"samples" minItems:"1" maxItems:"10" default:"1,2,3"`
+	Level    *int               `json:"level" nullable:"true" default:"3" enum:"1|2|3" description:"level of the reading"`
+	Secret   string             `json:"secret" writeOnly:"true" deprecated:"true"`
+	Labels   map[string]string  `json:"labels" minProperties:"1" maxProperties:"4"`
+	Enabled  bool               `json:"enabled" default:"true"`
+	Position struct{ X, Y int } `json:"position" default:"{\"X\":1,\"Y\":2}"`
+	Units    []string           `json:"units" enum:"celsius|kelvin"`
+	Steps    [2]int             `json:"steps" enum:"1|2|5"`
+}
+
+func TestJSONSchemaKeywordTags(t *testing.T) {
+	db := schemaBuilder{Schemas: &spec.Schemas{}, Config: Config{}}
+	db.addModelFrom(taggedReading{})
+	sc := (*db.Schemas)["restspec.taggedReading"].Value
+	t.Log(asJSON(sc))
+
+	value := sc.Properties["value"].Value
+	if *value.Min != 0 || !value.ExclusiveMin || *value.Max != 100 || !value.ExclusiveMax || *value.MultipleOf != 0.5 || value.Title != "Reading" {
+		t.Errorf("unexpected value %v", asJSON(value))
+	}
+	unit := sc.Properties["unit"].Value
+	if unit.Pattern != "^[a-z]+$" || unit.MinLength != 1 || *unit.MaxLength != 8 {
+		t.Errorf("unexpected unit %v", asJSON(unit))
+	}
+	if got, want := unit.Enum, []interface{}{"celsius", "kelvin"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+	samples := sc.Properties["samples"].Value
+	if samples.MinItems != 1 || *samples.MaxItems != 10 {
+		t.Errorf("unexpected samples %v", asJSON(samples))
+	}
+	if got, want := samples.Default, []interface{}{int64(1), int64(2), int64(3)}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+	level := sc.Properties["level"].Value
+	if !level.Nullable || level.Default != int64(3) || level.Enum[2] != int64(3) || level.Description != "level of the reading" {
+		t.Errorf("unexpected level %v", asJSON(level))
+	}
+	if secret := sc.Properties["secret"].Value; !secret.WriteOnly || !secret.Deprecated {
+		t.Errorf("unexpected secret %v", asJSON(secret))
+	}
+	if labels := sc.Properties["labels"].Value; labels.MinProps != 1 || *labels.MaxProps != 4 {
+		t.Errorf("unexpected labels %v", asJSON(labels))
+	}
+	if got, want := sc.Properties["enabled"].Value.Default, true; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := asJSON(sc.Properties["position"].Value.Default), asJSON(map[string]interface{}{"X": 1, "Y": 2}); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	// the values of a list are items
+	if got, want := sc.Properties["units"].Value.Enum, []interface{}{"celsius", "kelvin"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := sc.Properties["steps"].Value.Enum, []interface{}{int64(1), int64(2), int64(5)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+type badlyTagged struct {
+	Count int    `json:"count" default:"many" minimum:"ten"`
+	Name  string `json:"name" maxLength:"-1"`
+}
+
+func TestTagErrorsAreReported(t *testing.T) {
+	var errs []error
+	db := schemaBuilder{Schemas: &spec.Schemas{}, Config: Config{TagErrorHandler: func(err error) {
+		errs = append(errs, err)
+	}}}
+	db.addModelFrom(badlyTagged{})
+
+	if got, want := len(errs), 2; got != want {
+		t.Fatalf("got %v want %v: %v", got, want, errs)
+	}
+	for _, each := range []string{"model restspec.badlyTagged: field Count: tag default", "tag minimum"} {
+		if !strings.Contains(errs[0].Error(), each) {
+			t.Errorf("expected %q in %v", each, errs[0])
+		}
+	}
+	if !strings.Contains(errs[1].Error(), "field Name: tag maxLength") {
+		t.Errorf("unexpected error %v", errs[1])
+	}
+
+	// without a handler the errors are logged, unless ignored
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+	db = schemaBuilder{Schemas: &spec.Schemas{}, Config: Config{}}
+	db.addModelFrom(badlyTagged{})
+	if got := logged.String(); !strings.Contains(got, "restspec: model restspec.badlyTagged: field Count: tag default") {
+		t.Errorf("unexpected log %q", got)
+	}
+	logged.Reset()
+	db = schemaBuilder{Schemas: &spec.Schemas{}, Config: Config{TagErrorHandler: IgnoreTagErrors}}
+	db.addModelFrom(badlyTagged{})
+	if got := logged.String(); got != "" {
+		t.Errorf("unexpected log %q", got)
+	}
+}
+
+type optionalName struct {
+	Value string
+	Set   bool
+}
+
+func (optionalName) Nullable() bool { return true }
+
+type nullableRecord struct {
+	Name     *string        `json:"name"`
+	Note     sql.NullString `json:"note"`
+	Count    *sql.NullInt64 `json:"count"`
+	Nickname optionalName   `json:"nickname"`
+	Parent   *Item          `json:"parent" description:"parent item"`
+	Forced   *int           `json:"forced" x-nullable:"false"`
+	Plain    string         `json:"plain"`
+}
+
+func TestNullability(t *testing.T) {
+	db := schemaBuilder{Schemas: &spec.Schemas{}, Config: Config{}}
+	db.addModelFrom(nullableRecord{})
+	sc := (*db.Schemas)["restspec.nullableRecord"].Value
+	for name, want := range map[string]bool{"name": false, "nickname": true, "plain": false} {
+		if got := sc.Properties[name].Value.Nullable; got != want {
+			t.Errorf("%s: got %v want %v", name, got, want)
+		}
+	}
+	if got, want := sc.Properties["note"].Ref, "#/components/schemas/sql.NullString"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	db = schemaBuilder{Schemas: &spec.Schemas{}, Config: Config{Nullability: Nullability{Pointers: true, Wrappers: true}}}
+	db.addModelFrom(nullableRecord{})
+	sc = (*db.Schemas)["restspec.nullableRecord"].Value
+	t.Log(asJSON(sc))
+	for name, want := range map[string]bool{"name": true, "note": true, "count": true, "nickname": true, "forced": false, "plain": false} {
+		if got := sc.Properties[name].Value.Nullable; got != want {
+			t.Errorf("%s: got %v want %v", name, got, want)
+		}
+	}
+	if note := sc.Properties["note"].Value; !note.Type.Is("string") {
+		t.Errorf("unexpected note %v", asJSON(note))
+	}
+	if count := sc.Properties["count"].Value; !count.Type.Is("integer") {
+		t.Errorf("unexpected count %v", asJSON(count))
+	}
+	parent := sc.Properties["parent"]
+	if parent.Ref != "" || !parent.Value.Nullable || len(parent.Value.AllOf) != 1 || parent.Value.AllOf[0].Ref != "#/components/schemas/restspec.Item" {
+		t.Errorf("unexpected parent %v", asJSON(parent))
+	}
+	if got, want := parent.Value.Description, "parent item"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, ok := sc.Properties["forced"].Value.Extensions["x-nullable"]; !ok || got != false {
+		t.Errorf("got x-nullable %v", got)
+	}
+
+	db = schemaBuilder{Schemas: &spec.Schemas{}, Config: Config{Nullability: Nullability{Pointers: true, TypeNull: true}}}
+	db.addModelFrom(nullableRecord{})
+	sc = (*db.Schemas)["restspec.nullableRecord"].Value
+	if name := sc.Properties["name"].Value; name.Nullable || !name.Type.Includes("string") || !name.Type.Includes("null") {
+		t.Errorf("unexpected name %v", asJSON(name))
+	}
+	if parent := sc.Properties["parent"].Value; len(parent.OneOf) != 2 || !parent.OneOf[1].Value.Type.Is("null") {
+		t.Errorf("unexpected parent %v", asJSON(parent))
+	}
+	for typeNull, want := range map[bool]string{false: "3.0.1", true: "3.1.0"} {
+		if got := BuildOpenAPIV3(Config{Nullability: Nullability{TypeNull: typeNull}}).OpenAPI; got != want {
+			t.Errorf("TypeNull %v: got %v want %v", typeNull, got, want)
+		}
+	}
+}
+
+type Audited struct {
+	CreatedBy string `json:"createdBy"`
+}
+
+type auditedEmbed struct {
+	Embed
+	Audited `embed:"flatten"`
+	Name    string `json:"name"`
+}
+
+type onlyEmbed struct {
+	Embed `embed:"allOf"`
+}
+
+func TestEmbedAsAllOf(t *testing.T) {
+	db := schemaBuilder{Schemas: &spec.Schemas{}, Config: Config{EmbedAsAllOf: true}}
+	db.addModelFrom(auditedEmbed{})
+	t.Log(asJSON(db.Schemas))
+	if _, ok := (*db.Schemas)["restspec.Embed"]; !ok {
+		t.Fatal("missing component restspec.Embed")
+	}
+	sc := (*db.Schemas)["restspec.auditedEmbed"].Value
+	if len(sc.Properties) != 0 || len(sc.AllOf) != 2 || sc.AllOf[0].Ref != "#/components/schemas/restspec.Embed" {
+		t.Fatalf("unexpected schema %v", asJSON(sc))
+	}
+	own := sc.AllOf[1].Value
+	if _, ok := own.Properties["createdBy"]; !ok || len(own.Properties) != 2 {
+		t.Errorf("unexpected own properties %v", asJSON(own))
+	}
+	if got, want := strings.Join(own.Required, ","), "createdBy,name"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	// flattening is the default, the tag takes precedence
+	db = schemaBuilder{Schemas: &spec.Schemas{}, Config: Config{}}
+	db.addModelFrom(onlyEmbed{})
+	if sc := (*db.Schemas)["restspec.onlyEmbed"].Value; len(sc.AllOf) != 1 || len(sc.Properties) != 0 {
+		t.Errorf("unexpected schema %v", asJSON(sc))
+	}
+	db.addModelFrom(auditedEmbed{})
+	if sc := (*db.Schemas)["restspec.auditedEmbed"].Value; len(sc.AllOf) != 0 || len(sc.Properties) != 3 {
+		t.Errorf("unexpected schema %v", asJSON(sc))
+	}
+}
+
+type tagged struct {
+	Meta struct {
+		Labels []string `json:"labels"`
+	} `json:"meta" description:"meta of the model"`
+	Parts []struct {
+		Name string `json:"name"`
+	} `json:"parts"`
+}
+
+type versioned struct {
+	Meta struct {
+		Version int `json:"version"`
+	} `json:"meta"`
+}
+
+func TestAnonymousStructNames(t *testing.T) {
+	db := schemaBuilder{Schemas: &spec.Schemas{}, Config: Config{}}
+	db.addModelFrom(tagged{})
+	db.addModelFrom(versioned{})
+	t.Log(asJSON(db.Schemas))
+	for model, want := range map[string]string{"restspec.tagged": "restspec.tagged.meta", "restspec.versioned": "restspec.versioned.meta"} {
+		if got := refOf((*db.Schemas)[model].Value.Properties["meta"]); got != componentRoot+want {
+			t.Errorf("got %v want %v", got, want)
+		}
+	}
+	if _, ok := (*db.Schemas)["restspec.tagged.parts"].Value.Properties["name"]; !ok {
+		t.Errorf("missing component restspec.tagged.parts")
+	}
+	if _, ok := (*db.Schemas)["restspec.versioned.meta"].Value.Properties["version"]; !ok {
+		t.Errorf("missing component restspec.versioned.meta")
+	}
+
+	// names of the handler wanted by several types get a suffix in order of the types, whatever the order they are met in
+	for _, models := range [][]any{{tagged{}, versioned{}}, {versioned{}, tagged{}}} {
+		cfg := Config{AnonymousTypeNameHandler: func(model, field string) string { return field }}
+		ws := new(restful.WebService)
+		for _, model := range models {
+			ws.Route(ws.POST(fmt.Sprintf("/%T", model)).To(dummy).Reads(model))
+		}
+		schemas := buildSchemas(ws, cfg, collectNames(cfg, ws))
+		for model, want := range map[string]string{"restspec.tagged": "meta", "restspec.versioned": "meta_2"} {
+			if got := refOf(schemas[model].Value.Properties["meta"]); got != componentRoot+want {
+				t.Errorf("%T first: got %v want %v", models[0], got, want)
+			}
+		}
+	}
+}
+
+// refOf returns the reference of a property, kept in allOf if the property has keywords of its own.
+func refOf(prop *spec.SchemaRef) string {
+	if prop.Ref == "" && len(prop.Value.AllOf) == 1 {
+		return prop.Value.AllOf[0].Ref
+	}
+	return prop.Ref
+}
+
+type describedRefs struct {
+	Address net.IP `json:"address" description:"client address"`
+	Color   Color  `json:"color" description:"color of the paint" default:"blue"`
+	Accent  *Color `json:"accent" description:"second color" nullable:"true"`
+	Plain   Color  `json:"plain"`
+}
+
+func TestDescribedComponentRefs(t *testing.T) {
+	db := schemaBuilder{Schemas: &spec.Schemas{}, Config: Config{}}
+	db.addModelFrom(describedRefs{})
+	t.Log(asJSON(db.Schemas))
+	sc := (*db.Schemas)["restspec.describedRefs"].Value
+
+	// the keywords of the field are kept next to the reference
+	address := sc.Properties["address"]
+	if address.Ref != "" || refOf(address) != componentRoot+"net.IP" || address.Value.Description != "client address" {
+		t.Errorf("unexpected address %v", asJSON(address))
+	}
+	color := sc.Properties["color"]
+	if color.Ref != "" || refOf(color) != componentRoot+"restspec.Color" || color.Value.Description != "color of the paint" || color.Value.Default != "blue" {
+		t.Errorf("unexpected color %v", asJSON(color))
+	}
+	accent := sc.Properties["accent"]
+	if refOf(accent) != componentRoot+"restspec.Color" || accent.Value.Description != "second color" || !accent.Value.Nullable {
+		t.Errorf("unexpected accent %v", asJSON(accent))
+	}
+	if got, want := sc.Properties["plain"].Ref, componentRoot+"restspec.Color"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestInlineAnonymousStructs(t *testing.T) {
+	db := schemaBuilder{Schemas: &spec.Schemas{}, Config: Config{InlineAnonymousStructs: true}}
+	db.addModelFrom(tagged{})
+	t.Log(asJSON(db.Schemas))
+	if len(*db.Schemas) != 1 {
+		t.Fatalf("unexpected components %v", asJSON(db.Schemas))
+	}
+	sc := (*db.Schemas)["restspec.tagged"].Value
+	meta := sc.Properties["meta"]
+	if meta.Ref != "" || meta.Value.Description != "meta of the model" || meta.Value.Properties["labels"] == nil {
+		t.Errorf("unexpected meta %v", asJSON(meta.Value))
+	}
+	if parts := sc.Properties["parts"].Value.Items; parts.Ref != "" || parts.Value.Properties["name"] == nil {
+		t.Errorf("unexpected parts %v", asJSON(parts.Value))
+	}
+}
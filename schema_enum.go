@@ -0,0 +1,146 @@
+package restspec
+
+import (
+	"encoding"
+	"reflect"
+
+	spec "github.com/getkin/kin-openapi/openapi3"
+)
+
+// Extension Parameters of enums, as used by code generators
+const (
+	ExEnumVarNames     = "x-enum-varnames"
+	ExEnumDescriptions = "x-enum-descriptions"
+)
+
+// Enum is implemented by types with a fixed set of values, e.g. the constants declared of the type.
+// A value may be an EnumValue to document the name and a description of the value.
+// Fields and parameters of such a type refer to a component of the type, holding the values as enum.
+//
+//	type Color string
+//
+//	const (
+//		Red  Color = "red"
+//		Blue Color = "blue"
+//	)
+//
+//	func (Color) EnumValues() []any {
+//		return []any{
+//			restspec.EnumValue{Value: Red, Name: "Red"},
+//			restspec.EnumValue{Value: Blue, Name: "Blue", Description: "the color of the sky"},
+//		}
+//	}
+type Enum interface {
+	EnumValues() []any
+}
+
+// EnumValue documents a value of an Enum.
+type EnumValue struct {
+	// Value of the enum
+	Value any
+	// Name of the value, e.g. the name of the constant
+	Name string
+	// Description of the value
+	Description string
+}
+
+var enumType = reflect.TypeOf((*Enum)(nil)).Elem()
+
+// enumValues returns the values of an enum type, taken from the Enums of the config or the Enum interface.
+func (b *schemaBuilder) enumValues(rt reflect.Type) ([]any, bool) {
+	if rt == nil || rt.Kind() == reflect.Ptr || rt.Kind() == reflect.Interface {
+		return nil, false
+	}
+	if values, ok := b.Config.Enums[rt]; ok {
+		return values, true
+	}
+	if reflect.PointerTo(rt).Implements(enumType) {
+		return reflect.New(rt).Interface().(Enum).EnumValues(), true
+	}
+	return nil, false
+}
+
+// enumRef adds the component of an enum type, a pointer to one included, and returns the reference to it.
+func (b *schemaBuilder) enumRef(rt reflect.Type) (string, bool) {
+	rt = indirect(rt)
+	if _, ok := b.enumValues(rt); !ok {
+		return "", false
+	}
+	b.addModel(rt, "")
+	return componentRoot + keyFrom(rt, b.Config, b.Names), true
+}
+
+// setEnum documents the values of an enum type on its schema.
+func (b *schemaBuilder) setEnum(sm *spec.Schema, rt reflect.Type, values []any) {
+	name := keyFrom(rt, b.Config, b.Names)
+	sm.Type = &spec.Types{b.jsonSchemaType(name, rt.Kind())}
+	sm.Format = b.jsonSchemaFormat(name, rt.Kind())
+	text := marshalsText(rt)
+	if text {
+		// values are marshaled as text, e.g. by the names of the constants
+		sm.Type, sm.Format = &spec.Types{"string"}, ""
+	}
+	sm.Required = nil
+	sm.Properties = nil
+	sm.Description = docOfType(rt)
+
+	names := make([]string, 0, len(values))
+	descriptions := make([]string, 0, len(values))
+	named, described := true, false
+	for _, each := range values {
+		value := EnumValue{Value: each}
+		if ev, ok := each.(EnumValue); ok {
+			value = ev
+		}
+		value = enumValueDoc(rt, value)
+		if text {
+			sm.Enum = append(sm.Enum, textValue(value.Value, rt))
+		} else {
+			sm.Enum = append(sm.Enum, basicValue(value.Value))
+		}
+		names = append(names, value.Name)
+		descriptions = append(descriptions, value.Description)
+		named = named && value.Name != ""
+		described = described || value.Description != ""
+	}
+	if named {
+		sm.Extensions[ExEnumVarNames] = names
+	}
+	if described {
+		sm.Extensions[ExEnumDescriptions] = descriptions
+	}
+}
+
+// textValue marshals a value of an encoding.TextMarshaler of type rt into text.
+// A value which is not of that type, e.g. its text already, is kept.
+func textValue(value any, rt reflect.Type) any {
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() || !rv.Type().ConvertibleTo(rt) {
+		return value
+	}
+	pv := reflect.New(rt)
+	pv.Elem().Set(rv.Convert(rt))
+	text, err := pv.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return value
+	}
+	return string(text)
+}
+
+// basicValue converts a value of a named type, e.g. Color("red"), to its basic type.
+func basicValue(value any) any {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint()
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	}
+	return value
+}
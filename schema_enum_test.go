@@ -0,0 +1,129 @@
+package restspec
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	spec "github.com/getkin/kin-openapi/openapi3"
+)
+
+type Color string
+
+const (
+	Red  Color = "red"
+	Blue Color = "blue"
+)
+
+func (Color) EnumValues() []any {
+	return []any{
+		EnumValue{Value: Red, Name: "Red"},
+		EnumValue{Value: Blue, Name: "Blue", Description: "the color of the sky"},
+	}
+}
+
+type Level int
+
+const (
+	Low Level = iota + 1
+	High
+)
+
+type paint struct {
+	Main    Color            `json:"main"`
+	Accent  *Color           `json:"accent,omitempty"`
+	Palette []Color          `json:"palette"`
+	ByName  map[string]Color `json:"byName"`
+	Level   Level            `json:"level"`
+}
+
+var enumConfig = Config{Enums: map[reflect.Type][]any{reflect.TypeOf(Level(0)): {Low, High}}}
+
+func TestEnumModel(t *testing.T) {
+	db := schemaBuilder{Schemas: &spec.Schemas{}, Config: enumConfig}
+	db.addModelFrom(paint{})
+	t.Log(asJSON(db.Schemas))
+
+	color := (*db.Schemas)["restspec.Color"].Value
+	if !color.Type.Is("string") || !reflect.DeepEqual(color.Enum, []interface{}{"red", "blue"}) {
+		t.Errorf("unexpected color %v", asJSON(color))
+	}
+	if got, want := color.Extensions[ExEnumVarNames], []string{"Red", "Blue"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := color.Extensions[ExEnumDescriptions], []string{"", "the color of the sky"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	level := (*db.Schemas)["restspec.Level"].Value
+	if !level.Type.Is("integer") || !reflect.DeepEqual(level.Enum, []interface{}{int64(1), int64(2)}) {
+		t.Errorf("unexpected level %v", asJSON(level))
+	}
+	if _, ok := level.Extensions[ExEnumVarNames]; ok {
+		t.Errorf("unexpected var names %v", asJSON(level))
+	}
+
+	ref := "#/components/schemas/restspec.Color"
+	sc := (*db.Schemas)["restspec.paint"].Value
+	for name, got := range map[string]string{
+		"main":    sc.Properties["main"].Ref,
+		"accent":  sc.Properties["accent"].Ref,
+		"palette": sc.Properties["palette"].Value.Items.Ref,
+		"byName":  sc.Properties["byName"].Value.AdditionalProperties.Schema.Ref,
+	} {
+		if got != ref {
+			t.Errorf("%s: got %v want %v", name, got, ref)
+		}
+	}
+	if got, want := sc.Properties["level"].Ref, "#/components/schemas/restspec.Level"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+type paintInput struct {
+	Color  Color   `in:"query=color;default=red"`
+	Colors []Color `in:"query=colors"`
+	Level  Level   `in:"header=x-level"`
+	Warm   Color   `in:"query=warm;enum=red,yellow"`
+	Tints  []Color `in:"query=tints;pattern=^[a-z]+$;maxItems=3"`
+}
+
+func TestEnumParameters(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Path("/tests/enums")
+	ws.Route(ws.GET("").To(dummy).Do(ReadSample(paintInput{})))
+
+	p := buildPaths(ws, enumConfig, nil)
+	t.Log(asJSON(p))
+	params := p.Find("/tests/enums").Get.Parameters
+
+	ref := "#/components/schemas/restspec.Color"
+	color := params.GetByInAndName("query", "color").Schema
+	if color.Ref != "" || len(color.Value.AllOf) != 1 || color.Value.AllOf[0].Ref != ref || color.Value.Default != "red" {
+		t.Errorf("unexpected color %v", asJSON(color))
+	}
+	if colors := params.GetByInAndName("query", "colors").Schema.Value; !colors.Type.Is("array") || colors.Items.Ref != ref {
+		t.Errorf("unexpected colors %v", asJSON(colors))
+	}
+	if got, want := params.GetByInAndName("header", "x-level").Schema.Ref, "#/components/schemas/restspec.Level"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	// constraints of the input are kept next to the reference
+	if warm := params.GetByInAndName("query", "warm").Schema; warm.Ref != "" || len(warm.Value.AllOf) != 1 || warm.Value.AllOf[0].Ref != ref || len(warm.Value.Enum) != 2 || warm.Value.Type != nil {
+		t.Errorf("unexpected warm %v", asJSON(warm))
+	}
+	tints := params.GetByInAndName("query", "tints").Schema.Value
+	if items := tints.Items; items.Ref != "" || len(items.Value.AllOf) != 1 || items.Value.AllOf[0].Ref != ref || items.Value.Pattern != "^[a-z]+$" {
+		t.Errorf("unexpected tints %v", asJSON(tints))
+	}
+	if tints.MaxItems == nil || *tints.MaxItems != 3 {
+		t.Errorf("unexpected tints %v", asJSON(tints))
+	}
+
+	schemas := buildSchemas(ws, enumConfig, nil)
+	for _, name := range []string{"restspec.Color", "restspec.Level"} {
+		if _, ok := schemas[name]; !ok {
+			t.Errorf("missing component %s", name)
+		}
+	}
+}
@@ -1,6 +1,16 @@
 package restspec
 
-import "strings"
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/emicklei/go-restful/v3"
+)
 
 // DefaultNameHandler GoRestfulDefinition -> GoRestfulDefinition (not changed)
 func DefaultNameHandler(name string) string {
@@ -103,3 +113,443 @@ var commonInitialisms = map[string]bool{
 func isUpper(r uint8) bool {
 	return 'A' <= r && r <= 'Z'
 }
+
+// validName matches the names of components allowed by OpenAPI.
+var validName = regexp.MustCompile(`^[a-zA-Z0-9.\-_]+$`)
+
+// componentNames holds the names of the components of the types of a document, resolved by collectNames
+// before the document is built. Named types of different packages with the same name, e.g. v1.User
+// of two packages, are all qualified by the path of their package, e.g. github.com.a.v1.User and
+// github.com.b.v1.User. Anonymous types wanting the same name, e.g. by the AnonymousTypeNameHandler, get
+// a suffix in order of their types, e.g. meta and meta_2. So the names do not depend on the order types
+// are met in. Types which were not collected, or without names, e.g. when building a single model,
+// are given the name they want.
+type componentNames struct {
+	types     map[reflect.Type]string
+	anonymous map[wantedName]string
+}
+
+// wantedName is a name wanted by an anonymous type, e.g. restspec.Order.meta.
+// The same anonymous type may want several names.
+type wantedName struct {
+	name string
+	st   reflect.Type
+}
+
+// typeName returns the name of the component of a named type wanting the given name.
+func (n *componentNames) typeName(st reflect.Type, name string) string {
+	if n != nil {
+		if assigned, ok := n.types[st]; ok {
+			return assigned
+		}
+	}
+	return name
+}
+
+// anonymousName returns the name of the component of an anonymous type wanting the given name.
+func (n *componentNames) anonymousName(name string, st reflect.Type) string {
+	if n != nil {
+		if assigned, ok := n.anonymous[wantedName{name: name, st: st}]; ok {
+			return assigned
+		}
+	}
+	return name
+}
+
+// takenNames are the names assigned while resolving, with the type they are assigned to.
+type takenNames map[string]reflect.Type
+
+// free returns the name, with a suffix if taken by another type.
+func (taken takenNames) free(name string, st reflect.Type) string {
+	base := name
+	for i := 2; taken[name] != nil && taken[name] != st; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	taken[name] = st
+	return name
+}
+
+// resolveNames assigns the names wanted by types, in order of the names and of the types wanting the same one.
+// Names wanted by a single type go first, so that qualified names or suffixes do not take them.
+// It calls assign with each type and the name assigned to it.
+func resolveNames(wanted map[string][]reflect.Type, taken takenNames, qualify bool, assign func(name string, st reflect.Type, assigned string)) {
+	names := make([]string, 0, len(wanted))
+	for name := range wanted {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if types := wanted[name]; len(types) == 1 {
+			assign(name, types[0], taken.free(name, types[0]))
+		}
+	}
+	for _, name := range names {
+		types := wanted[name]
+		if len(types) == 1 {
+			continue
+		}
+		sort.Slice(types, func(i, j int) bool { return typeOrder(types[i]) < typeOrder(types[j]) })
+		for _, st := range types {
+			candidate := name
+			if qualify {
+				candidate = qualifiedName(name, st)
+			}
+			assign(name, st, taken.free(candidate, st))
+		}
+	}
+}
+
+// collectNames resolves the names of the components of the types of the services. It only walks the types,
+// without building their schemas, so none of the handlers or methods documenting a type are called.
+func collectNames(cfg Config, services ...*restful.WebService) *componentNames {
+	c := nameCollector{
+		cfg:       cfg,
+		wanted:    map[string][]reflect.Type{},
+		seen:      map[reflect.Type]bool{},
+		anonymous: map[anonymousType]*anonymousType{},
+	}
+	for _, ws := range services {
+		for _, r := range ws.Routes() {
+			c.addRoute(r)
+		}
+	}
+	return c.resolve()
+}
+
+// nameCollector collects the types of a document wanting names of components.
+type nameCollector struct {
+	cfg Config
+	// named types by the name they want
+	wanted map[string][]reflect.Type
+	seen   map[reflect.Type]bool
+	// anonymous structs in order they are met in
+	anonymous map[anonymousType]*anonymousType
+	order     []*anonymousType
+}
+
+// fieldOwner is the model of a field, a type or the anonymous struct of another field.
+type fieldOwner struct {
+	model     reflect.Type
+	anonymous *anonymousType
+}
+
+// anonymousType is the anonymous struct of a field, named after the model and the field.
+type anonymousType struct {
+	owner fieldOwner
+	field string
+	st    reflect.Type
+}
+
+// addRoute collects the types of the models of a route, as addSchemaFromRouteTo adds them.
+func (c *nameCollector) addRoute(r restful.Route) {
+	samples := []any{r.ReadSample}
+	if _, ok := downloadOf(r.WriteSample); !ok {
+		samples = append(samples, r.WriteSample)
+	}
+	for _, param := range r.ParameterDocs {
+		if mt, ok := param.Data().Extensions[keyParamModel].(reflect.Type); ok {
+			c.addModel(mt)
+		}
+		if et, ok := param.Data().Extensions[keyParamType].(reflect.Type); ok && c.ownsSchema(indirect(et)) {
+			c.model(indirect(et), nil)
+		}
+	}
+	for _, v := range r.Metadata {
+		if callback, ok := v.(Callback); ok {
+			samples = append(samples, callback.Reads)
+			for _, e := range callback.Returns {
+				samples = append(samples, e.Model)
+			}
+		}
+		if stream, ok := v.(Stream); ok {
+			samples = append(samples, stream.Item)
+			for _, event := range stream.Events {
+				samples = append(samples, event.Data)
+			}
+		}
+	}
+	for _, v := range r.ResponseErrors {
+		if _, ok := downloadOf(v.Model); !ok {
+			samples = append(samples, v.Model)
+		}
+	}
+	for _, sample := range samples {
+		if sample != nil {
+			c.addModel(reflect.TypeOf(sample))
+		}
+	}
+}
+
+// addModel collects the type of a model, the type of its elements for a list, see schemaBuilder.addModel.
+func (c *nameCollector) addModel(st reflect.Type) {
+	if st.Kind() == reflect.Ptr {
+		st = st.Elem()
+	}
+	if !c.ownsSchema(st) && (st.Kind() == reflect.Slice || st.Kind() == reflect.Array) {
+		st = st.Elem()
+	}
+	c.model(st, nil)
+}
+
+// model collects the type of a model, anonymous if it is the struct of a field, and the types of its fields.
+func (c *nameCollector) model(st reflect.Type, anonymous *anonymousType) {
+	st = indirect(st)
+	if anonymous == nil {
+		if c.seen[st] {
+			return
+		}
+		c.seen[st] = true
+		if st.Name() != "" {
+			if _, ok := c.override(st); !ok {
+				c.wanted[componentName(st)] = append(c.wanted[componentName(st)], st)
+			}
+		}
+	}
+	if c.ownsSchema(st) {
+		return
+	}
+	owner := fieldOwner{model: st, anonymous: anonymous}
+	switch st.Kind() {
+	case reflect.Map:
+		c.value(st, owner, "value")
+	case reflect.Struct:
+		b := schemaBuilder{Config: c.cfg}
+		for i := 0; i < st.NumField(); i++ {
+			field := st.Field(i)
+			jsonName := b.jsonNameOfField(field)
+			if jsonName == "" || field.Name == "XMLName" && field.Type.String() == "xml.Name" {
+				continue
+			}
+			if value, ok := nullWrapperValue(field.Type); ok && c.cfg.Nullability.Wrappers {
+				field.Type = value.Type
+			}
+			if opts := strings.Split(field.Tag.Get("json"), ","); field.Tag.Get("type") != "" || len(opts) > 1 && opts[1] == "string" {
+				continue
+			}
+			c.value(field.Type, owner, jsonName)
+		}
+	}
+}
+
+// value collects the types of a value of a field, its items or the values of a map.
+func (c *nameCollector) value(rt reflect.Type, owner fieldOwner, field string) {
+	rt = indirect(rt)
+	if c.ownsSchema(rt) {
+		c.model(rt, nil)
+		return
+	}
+	switch rt.Kind() {
+	case reflect.Interface:
+		for _, sample := range c.cfg.Interfaces[rt].Implementations {
+			c.model(reflect.TypeOf(sample), nil)
+		}
+	case reflect.Slice, reflect.Array:
+		if rt.Elem().Kind() != reflect.Uint8 {
+			c.value(rt.Elem(), owner, field)
+		}
+	case reflect.Map:
+		if kt := rt.Key(); c.ownsSchema(kt) {
+			c.model(kt, nil)
+		}
+		c.value(rt.Elem(), owner, field)
+	case reflect.Struct:
+		if rt.Name() != "" {
+			c.model(rt, nil)
+			return
+		}
+		key := anonymousType{owner: owner, field: field, st: rt}
+		if _, ok := c.anonymous[key]; ok {
+			return
+		}
+		anonymous := &key
+		c.anonymous[key] = anonymous
+		c.order = append(c.order, anonymous)
+		c.model(rt, anonymous)
+	default:
+		c.model(rt, nil)
+	}
+}
+
+// ownsSchema reports whether a type describes its own schema or values, see schemaBuilder.ownSchema
+// and schemaBuilder.enumValues, without asking it for these.
+func (c *nameCollector) ownsSchema(rt reflect.Type) bool {
+	if rt.Kind() == reflect.Ptr || rt.Kind() == reflect.Interface {
+		return false
+	}
+	if _, ok := c.cfg.Enums[rt]; ok || c.cfg.TypeSchemas[rt] != nil {
+		return true
+	}
+	pt := reflect.PointerTo(rt)
+	if pt.Implements(schemaProviderType) || pt.Implements(enumType) {
+		return true
+	}
+	_, ok := marshalerSchema(rt)
+	return ok
+}
+
+// override returns the name of a type given by the ModelTypeNameHandler of the config.
+func (c *nameCollector) override(st reflect.Type) (string, bool) {
+	if c.cfg.ModelTypeNameHandler == nil {
+		return "", false
+	}
+	return c.cfg.ModelTypeNameHandler(st)
+}
+
+// resolve assigns the names of the named types, then of the anonymous structs, which depend on the names
+// of their models, those of the fields of models first.
+func (c *nameCollector) resolve() *componentNames {
+	names := &componentNames{types: map[reflect.Type]string{}, anonymous: map[wantedName]string{}}
+	taken := takenNames{}
+	resolveNames(c.wanted, taken, true, func(_ string, st reflect.Type, assigned string) {
+		names.types[st] = assigned
+	})
+	assigned := map[*anonymousType]string{}
+	for pending := c.order; len(pending) > 0; {
+		wanted := map[string][]reflect.Type{}
+		wants := map[*anonymousType]string{}
+		var next []*anonymousType
+		for _, each := range pending {
+			model := ""
+			if parent := each.owner.anonymous; parent == nil {
+				model = keyFrom(each.owner.model, c.cfg, names)
+			} else if name, ok := assigned[parent]; ok {
+				model = name
+			} else {
+				next = append(next, each)
+				continue
+			}
+			name := model + "." + each.field
+			if c.cfg.AnonymousTypeNameHandler != nil {
+				name = c.cfg.AnonymousTypeNameHandler(model, each.field)
+			}
+			name = sanitizeName(name)
+			wants[each] = name
+			if !slices.Contains(wanted[name], each.st) {
+				wanted[name] = append(wanted[name], each.st)
+			}
+		}
+		resolveNames(wanted, taken, false, func(name string, st reflect.Type, assignedName string) {
+			names.anonymous[wantedName{name: name, st: st}] = assignedName
+		})
+		for each, name := range wants {
+			assigned[each] = names.anonymous[wantedName{name: name, st: each.st}]
+		}
+		pending = next
+	}
+	return names
+}
+
+// qualifiedName returns the name of a named type qualified by the path of its package,
+// e.g. math.rand.v2.Rand for rand.Rand of math/rand/v2, else the name.
+func qualifiedName(name string, st reflect.Type) string {
+	pkg := st.PkgPath()
+	if pkg == "" || st.Name() == "" {
+		return name
+	}
+	_, local, _ := strings.Cut(name, ".")
+	return sanitizeName(strings.ReplaceAll(pkg, "/", ".") + "." + local)
+}
+
+// typeOrder orders the types wanting the same name.
+func typeOrder(st reflect.Type) string {
+	return st.PkgPath() + " " + st.String()
+}
+
+// componentName returns the name of a named type, e.g. restspec.Sample. A generic type gets
+// a readable name, e.g. restspec.PageOfUser for restspec.Page[github.com/x/y.User].
+func componentName(st reflect.Type) string {
+	name := st.Name()
+	pkg := strings.TrimSuffix(st.String(), name)
+	if strings.HasSuffix(name, "]") {
+		name = readableName(name)
+	}
+	return sanitizeName(pkg + name)
+}
+
+// readableName returns a name without package of a type as written by reflect, e.g.
+// UserList for []github.com/x/y.User or MapOfStringToInt for map[string]int.
+func readableName(s string) string {
+	s = strings.TrimLeft(s, "*")
+	switch {
+	case strings.HasPrefix(s, "["):
+		end := closingBracket(s, 0)
+		return readableName(s[end+1:]) + "List"
+	case strings.HasPrefix(s, "map["):
+		end := closingBracket(s, 3)
+		return "MapOf" + readableName(s[4:end]) + "To" + readableName(s[end+1:])
+	case strings.HasPrefix(s, "struct"):
+		return "Object"
+	case strings.HasPrefix(s, "interface"):
+		return "Any"
+	}
+
+	base, args := s, ""
+	if i := strings.IndexByte(s, '['); i > 0 && strings.HasSuffix(s, "]") {
+		base, args = s[:i], s[i+1:len(s)-1]
+	}
+	base = base[strings.LastIndexByte(base, '/')+1:]
+	base = base[strings.LastIndexByte(base, '.')+1:]
+	name := strings.ToUpper(base[:min(1, len(base))]) + base[min(1, len(base)):]
+	if args == "" {
+		return name
+	}
+	names := []string{}
+	for _, each := range typeArgs(args) {
+		names = append(names, readableName(each))
+	}
+	return name + "Of" + strings.Join(names, "And")
+}
+
+// closingBracket returns the index of the bracket closing the one at index open, or the last index.
+func closingBracket(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(s) - 1
+}
+
+// typeArgs splits the type arguments of a generic type, skipping commas within brackets, braces and tags.
+func typeArgs(s string) (args []string) {
+	depth, start, quoted := 0, 0, false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quoted:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				quoted = false
+			}
+		case c == '"':
+			quoted = true
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			args = append(args, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(args, s[start:])
+}
+
+// sanitizeName replaces the characters not allowed in names of components by _.
+func sanitizeName(name string) string {
+	if validName.MatchString(name) {
+		return name
+	}
+	return strings.Map(func(r rune) rune {
+		if r < utf8.RuneSelf && validName.MatchString(string(r)) {
+			return r
+		}
+		return '_'
+	}, name)
+}
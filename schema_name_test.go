@@ -0,0 +1,120 @@
+package restspec
+
+import (
+	mathrand "math/rand"
+	randv2 "math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	spec "github.com/getkin/kin-openapi/openapi3"
+)
+
+type Page[T any] struct {
+	Items []T `json:"items"`
+}
+
+type Pair[A, B any] struct {
+	First  A `json:"first"`
+	Second B `json:"second"`
+}
+
+func TestComponentNames(t *testing.T) {
+	for _, each := range []struct {
+		sample any
+		want   string
+	}{
+		{Item{}, "restspec.Item"},
+		{[]*Item{}, "restspec.Item"},
+		{Page[Item]{}, "restspec.PageOfItem"},
+		{Pair[int, Page[*Item]]{}, "restspec.PairOfIntAndPageOfItem"},
+		{Page[map[string][]Item]{}, "restspec.PageOfMapOfStringToItemList"},
+		{Page[struct{ A, B int }]{}, "restspec.PageOfObject"},
+		{map[string][]Item{}, "MapOfStringToItemList"},
+	} {
+		if got := keyFrom(reflect.TypeOf(each.sample), Config{}, nil); got != each.want {
+			t.Errorf("got %v want %v", got, each.want)
+		}
+	}
+
+	cfg := Config{ModelTypeNameHandler: func(t reflect.Type) (string, bool) {
+		return "Items", t == reflect.TypeOf(Page[Item]{})
+	}}
+	if got, want := keyFrom(reflect.TypeOf(Page[Item]{}), cfg, nil), "Items"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestComponentNameCollisions(t *testing.T) {
+	// types of different packages with the same name are all qualified, whatever the order they are met in
+	for _, samples := range [][]any{{mathrand.Rand{}, randv2.Rand{}}, {randv2.Rand{}, mathrand.Rand{}}} {
+		ws := new(restful.WebService)
+		ws.Path("/tests/names").Produces(restful.MIME_JSON)
+		ws.Route(ws.GET("/first").To(dummy).Returns(200, "ok", samples[0]))
+		ws.Route(ws.GET("/second").To(dummy).Returns(200, "ok", samples[1]))
+		ws.Route(ws.GET("/pages").To(dummy).Returns(200, "ok", Page[Item]{}))
+
+		openapi := BuildOpenAPIV3(Config{WebServices: []*restful.WebService{ws}})
+		t.Log(asJSON(openapi.Components.Schemas))
+		for _, name := range []string{"math.rand.Rand", "math.rand.v2.Rand", "restspec.PageOfItem", "restspec.Item"} {
+			if _, ok := openapi.Components.Schemas[name]; !ok {
+				t.Errorf("missing component %s", name)
+			}
+			if !validName.MatchString(name) {
+				t.Errorf("invalid name %s", name)
+			}
+		}
+		if _, ok := openapi.Components.Schemas["rand.Rand"]; ok {
+			t.Errorf("unexpected component rand.Rand")
+		}
+		want := strings.ReplaceAll(reflect.TypeOf(samples[0]).PkgPath(), "/", ".") + ".Rand"
+		first := openapi.Paths.Find("/tests/names/first").Get.Responses.Status(200).Value.Content.Get(restful.MIME_JSON)
+		if got := first.Schema.Ref; got != componentRoot+want {
+			t.Errorf("got %v want %v", got, want)
+		}
+	}
+}
+
+var builtSchemas, providedSchemas int
+
+type builtOnce struct {
+	Count    int          `json:"count" default:"many"`
+	Provided providedOnce `json:"provided"`
+}
+
+func (builtOnce) PostBuildOpenAPISchemaHandler(*spec.Schema) { builtSchemas++ }
+
+type providedOnce struct{}
+
+func (providedOnce) OpenAPISchema() *spec.Schema {
+	providedSchemas++
+	return spec.NewStringSchema()
+}
+
+func TestCollectNamesWithoutSideEffects(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Path("/tests/once")
+	ws.Route(ws.GET("").To(dummy).Returns(200, "ok", builtOnce{}))
+
+	builtSchemas, providedSchemas = 0, 0
+	tagErrors := 0
+	cfg := Config{WebServices: []*restful.WebService{ws}, TagErrorHandler: func(error) { tagErrors++ }}
+	names := collectNames(cfg, ws)
+	if builtSchemas != 0 || providedSchemas != 0 || tagErrors != 0 {
+		t.Errorf("collecting names built %d, provided %d schemas and reported %d tag errors", builtSchemas, providedSchemas, tagErrors)
+	}
+	if got, want := names.typeName(reflect.TypeOf(builtOnce{}), "wanted"), "restspec.builtOnce"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	// the document is built once
+	buildPaths(ws, cfg, names)
+	buildSchemas(ws, cfg, names)
+	provided := providedSchemas
+	builtSchemas, providedSchemas, tagErrors = 0, 0, 0
+	BuildOpenAPIV3(cfg)
+	if builtSchemas != 1 || providedSchemas != provided || tagErrors != 1 {
+		t.Errorf("built %d, provided %d schemas and reported %d tag errors, want 1, %d and 1", builtSchemas, providedSchemas, tagErrors, provided)
+	}
+}
@@ -0,0 +1,69 @@
+package restspec
+
+import (
+	"reflect"
+	"sort"
+
+	spec "github.com/getkin/kin-openapi/openapi3"
+)
+
+// Polymorph documents the implementations of an interface type, see Config.Interfaces.
+// Fields, items and map values of the interface type are documented as one of the implementations.
+//
+//	cfg.Interfaces = map[reflect.Type]restspec.Polymorph{
+//		reflect.TypeOf((*Shape)(nil)).Elem(): {
+//			PropertyName:    "type",
+//			Implementations: map[string]any{"circle": Circle{}, "square": Square{}},
+//		},
+//	}
+type Polymorph struct {
+	// PropertyName is the name of the property telling the implementations apart, e.g. "type".
+	// Without it there is no discriminator.
+	PropertyName string
+	// Implementations maps the values of the property onto samples of the implementations
+	Implementations map[string]any
+	// AnyOf documents the implementations as anyOf instead of oneOf
+	AnyOf bool
+}
+
+// setPolymorph documents a value of a registered interface type on its schema as one of the
+// implementations, and adds the components of the implementations. It reports whether rt is registered.
+func (b *schemaBuilder) setPolymorph(sm *spec.Schema, rt reflect.Type) bool {
+	if rt.Kind() != reflect.Interface {
+		return false
+	}
+	poly, ok := b.Config.Interfaces[rt]
+	if !ok {
+		return false
+	}
+
+	values := make([]string, 0, len(poly.Implementations))
+	for value := range poly.Implementations {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	refs := spec.SchemaRefs{}
+	mapping := spec.StringMap{}
+	seen := map[string]bool{}
+	for _, value := range values {
+		it := indirect(reflect.TypeOf(poly.Implementations[value]))
+		b.addModel(it, "")
+		ref := componentRoot + keyFrom(it, b.Config, b.Names)
+		mapping[value] = ref
+		// an implementation may have several values
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, &spec.SchemaRef{Ref: ref, Value: spec.NewSchema()})
+		}
+	}
+	if poly.AnyOf {
+		sm.AnyOf = refs
+	} else {
+		sm.OneOf = refs
+	}
+	if poly.PropertyName != "" {
+		sm.Discriminator = &spec.Discriminator{PropertyName: poly.PropertyName, Mapping: mapping}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package restspec
+
+import (
+	"reflect"
+	"testing"
+
+	spec "github.com/getkin/kin-openapi/openapi3"
+)
+
+type Occurrence interface {
+	OccurrenceType() string
+}
+
+type Created struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+func (Created) OccurrenceType() string { return "created" }
+
+type Deleted struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+func (*Deleted) OccurrenceType() string { return "deleted" }
+
+type eventLog struct {
+	Last    Occurrence            `json:"last" description:"last event"`
+	Events  []Occurrence          `json:"events"`
+	ByName  map[string]Occurrence `json:"byName"`
+	Payload interface{}           `json:"payload"`
+}
+
+func TestPolymorphicFields(t *testing.T) {
+	cfg := Config{Interfaces: map[reflect.Type]Polymorph{
+		reflect.TypeOf((*Occurrence)(nil)).Elem(): {
+			PropertyName:    "type",
+			Implementations: map[string]any{"created": Created{}, "deleted": &Deleted{}, "removed": &Deleted{}},
+		},
+		reflect.TypeOf((*interface{})(nil)).Elem(): {
+			Implementations: map[string]any{"created": Created{}},
+			AnyOf:           true,
+		},
+	}}
+	db := schemaBuilder{Schemas: &spec.Schemas{}, Config: cfg}
+	db.addModelFrom(eventLog{})
+	t.Log(asJSON(db.Schemas))
+
+	for _, name := range []string{"restspec.Created", "restspec.Deleted"} {
+		if _, ok := (*db.Schemas)[name]; !ok {
+			t.Errorf("missing component %s", name)
+		}
+	}
+	sc := (*db.Schemas)["restspec.eventLog"].Value
+	last := sc.Properties["last"]
+	if last.Ref != "" || last.Value.Description != "last event" {
+		t.Errorf("unexpected last %v", asJSON(last))
+	}
+	for name, each := range map[string]*spec.Schema{
+		"last":   last.Value,
+		"events": sc.Properties["events"].Value.Items.Value,
+		"byName": sc.Properties["byName"].Value.AdditionalProperties.Schema.Value,
+	} {
+		if len(each.OneOf) != 2 || each.OneOf[0].Ref != "#/components/schemas/restspec.Created" || each.OneOf[1].Ref != "#/components/schemas/restspec.Deleted" {
+			t.Errorf("%s: unexpected oneOf %v", name, asJSON(each))
+		}
+		want := spec.StringMap{
+			"created": "#/components/schemas/restspec.Created",
+			"deleted": "#/components/schemas/restspec.Deleted",
+			"removed": "#/components/schemas/restspec.Deleted",
+		}
+		if each.Discriminator == nil || each.Discriminator.PropertyName != "type" || !reflect.DeepEqual(each.Discriminator.Mapping, want) {
+			t.Errorf("%s: unexpected discriminator %v", name, asJSON(each))
+		}
+	}
+	if payload := sc.Properties["payload"].Value; len(payload.AnyOf) != 1 || payload.Discriminator != nil {
+		t.Errorf("unexpected payload %v", asJSON(payload))
+	}
+}
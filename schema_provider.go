@@ -0,0 +1,107 @@
+package restspec
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+
+	spec "github.com/getkin/kin-openapi/openapi3"
+)
+
+// SchemaProvider is implemented by types which describe their own schema, replacing the one built by
+// reflection, e.g. a UUID as a string of format uuid. Fields, bodies, responses, map values and slice
+// elements of such a type refer to a component of the type, holding its schema.
+//
+//	func (UUID) OpenAPISchema() *openapi3.Schema {
+//		return openapi3.NewStringSchema().WithFormat("uuid")
+//	}
+type SchemaProvider interface {
+	OpenAPISchema() *spec.Schema
+}
+
+// ExPropertyNames is the extension holding the schema of the keys of a map, like propertyNames of JSON Schema,
+// if these are not plain strings.
+const ExPropertyNames = "x-property-names"
+
+var (
+	schemaProviderType = reflect.TypeOf((*SchemaProvider)(nil)).Elem()
+	jsonMarshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType  = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// ownSchema returns the schema of a type describing its own schema, taken from the TypeSchemas of the config
+// or the SchemaProvider interface, or else of a type marshaling itself unless it is an Enum.
+func (b *schemaBuilder) ownSchema(rt reflect.Type) (*spec.Schema, bool) {
+	if rt == nil || rt.Kind() == reflect.Ptr || rt.Kind() == reflect.Interface {
+		return nil, false
+	}
+	if sm, ok := b.Config.TypeSchemas[rt]; ok && sm != nil {
+		return sm, true
+	}
+	if reflect.PointerTo(rt).Implements(schemaProviderType) {
+		if sm := reflect.New(rt).Interface().(SchemaProvider).OpenAPISchema(); sm != nil {
+			return sm, true
+		}
+	}
+	if _, ok := b.enumValues(rt); ok {
+		return nil, false
+	}
+	return marshalerSchema(rt)
+}
+
+// marshalerSchema returns the schema of a type marshaling itself. A json.Marshaler is opaque, i.e. any value,
+// and an encoding.TextMarshaler a string. time.Time is a date-time instead, see isPrimitiveType.
+func marshalerSchema(rt reflect.Type) (*spec.Schema, bool) {
+	if rt.String() == "time.Time" {
+		return nil, false
+	}
+	switch pt := reflect.PointerTo(rt); {
+	case pt.Implements(jsonMarshalerType):
+		return &spec.Schema{}, true
+	case pt.Implements(textMarshalerType):
+		return spec.NewStringSchema(), true
+	}
+	return nil, false
+}
+
+// marshalsText reports whether values of a type are marshaled as text, i.e. JSON strings.
+func marshalsText(rt reflect.Type) bool {
+	pt := reflect.PointerTo(rt)
+	return pt.Implements(textMarshalerType) && !pt.Implements(jsonMarshalerType)
+}
+
+// keySchema returns the schema of the keys of a map type, or nil if these are plain strings.
+// JSON encodes keys of an encoding.TextMarshaler as text, and integers as decimal strings.
+func (b *schemaBuilder) keySchema(mapType reflect.Type) *spec.SchemaRef {
+	kt := mapType.Key()
+	if kt.Kind() == reflect.String || marshalsText(kt) {
+		if ref, ok := b.componentRef(kt); ok {
+			return &spec.SchemaRef{Ref: ref, Value: spec.NewSchema()}
+		}
+		return nil
+	}
+	switch {
+	case reflect.Int <= kt.Kind() && kt.Kind() <= reflect.Int64:
+		return &spec.SchemaRef{Value: spec.NewStringSchema().WithPattern(`^-?[0-9]+$`)}
+	case reflect.Uint <= kt.Kind() && kt.Kind() <= reflect.Uintptr:
+		return &spec.SchemaRef{Value: spec.NewStringSchema().WithPattern(`^[0-9]+$`)}
+	}
+	return nil
+}
+
+// hasOwnSchema reports whether a type, or the type a pointer points to, describes its own schema.
+func hasOwnSchema(rt reflect.Type, cfg Config) bool {
+	b := schemaBuilder{Config: cfg}
+	_, ok := b.ownSchema(indirect(rt))
+	return ok
+}
+
+// componentRef adds the component of a type defining its own schema or values, see SchemaProvider and Enum,
+// and returns the reference to it. A pointer to such a type refers to the same component.
+func (b *schemaBuilder) componentRef(rt reflect.Type) (string, bool) {
+	if _, ok := b.ownSchema(indirect(rt)); ok {
+		b.addModel(indirect(rt), "")
+		return componentRoot + keyFrom(indirect(rt), b.Config, b.Names), true
+	}
+	return b.enumRef(rt)
+}
@@ -0,0 +1,168 @@
+package restspec
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	spec "github.com/getkin/kin-openapi/openapi3"
+)
+
+type UUID [16]byte
+
+func (UUID) OpenAPISchema() *spec.Schema {
+	return spec.NewStringSchema().WithFormat("uuid")
+}
+
+// nolint:unused
+type Money struct {
+	units int64
+	nanos int32
+}
+
+type invoice struct {
+	ID     UUID              `json:"id"`
+	Parent *UUID             `json:"parent,omitempty"`
+	Lines  []UUID            `json:"lines"`
+	ByName map[string]UUID   `json:"byName"`
+	Groups map[string][]UUID `json:"groups"`
+	Total  Money             `json:"total"`
+}
+
+var moneyConfig = Config{TypeSchemas: map[reflect.Type]*spec.Schema{
+	reflect.TypeOf(Money{}): spec.NewStringSchema().WithPattern(`^-?[0-9]+(\.[0-9]+)?$`),
+}}
+
+func TestOwnSchemaFields(t *testing.T) {
+	db := schemaBuilder{Schemas: &spec.Schemas{}, Config: moneyConfig}
+	db.addModelFrom(invoice{})
+	t.Log(asJSON(db.Schemas))
+
+	if id := (*db.Schemas)["restspec.UUID"].Value; !id.Type.Is("string") || id.Format != "uuid" || len(id.Extensions) != 0 {
+		t.Errorf("unexpected UUID %v", asJSON(id))
+	}
+	if money := (*db.Schemas)["restspec.Money"].Value; !money.Type.Is("string") || money.Pattern == "" || len(money.Properties) != 0 {
+		t.Errorf("unexpected Money %v", asJSON(money))
+	}
+
+	ref := "#/components/schemas/restspec.UUID"
+	sc := (*db.Schemas)["restspec.invoice"].Value
+	for name, got := range map[string]string{
+		"id":     sc.Properties["id"].Ref,
+		"parent": sc.Properties["parent"].Ref,
+		"lines":  sc.Properties["lines"].Value.Items.Ref,
+		"byName": sc.Properties["byName"].Value.AdditionalProperties.Schema.Ref,
+		"groups": sc.Properties["groups"].Value.AdditionalProperties.Schema.Value.Items.Ref,
+	} {
+		if got != ref {
+			t.Errorf("%s: got %v want %v", name, got, ref)
+		}
+	}
+	if got, want := sc.Properties["total"].Ref, "#/components/schemas/restspec.Money"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestOwnSchemaBodiesAndResponses(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Path("/tests/own").Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON)
+	ws.Route(ws.POST("").To(dummy).
+		Reads(UUID{}).
+		Returns(200, "ok", []UUID{}).
+		Returns(201, "created", Money{}))
+
+	p := buildPaths(ws, moneyConfig, nil)
+	t.Log(asJSON(p))
+	op := p.Find("/tests/own").Post
+
+	ref := "#/components/schemas/restspec.UUID"
+	if body := op.RequestBody.Value.Content.Get(restful.MIME_JSON).Schema; body.Ref != ref {
+		t.Errorf("unexpected body %v", asJSON(body))
+	}
+	if ok := op.Responses.Status(200).Value.Content.Get(restful.MIME_JSON).Schema.Value; !ok.Type.Is("array") || ok.Items.Ref != ref {
+		t.Errorf("unexpected response %v", asJSON(ok))
+	}
+	if created := op.Responses.Status(201).Value.Content.Get(restful.MIME_JSON).Schema; created.Ref != "#/components/schemas/restspec.Money" {
+		t.Errorf("unexpected response %v", asJSON(created))
+	}
+
+	schemas := buildSchemas(ws, moneyConfig, nil)
+	for _, name := range []string{"restspec.UUID", "restspec.Money"} {
+		if _, ok := schemas[name]; !ok {
+			t.Errorf("missing component %s", name)
+		}
+	}
+}
+
+// nolint:unused
+type ticketID struct {
+	n int
+}
+
+func (id ticketID) MarshalText() ([]byte, error) { return []byte(fmt.Sprint("T-", id.n)), nil }
+
+// nolint:unused
+type rawPayload struct {
+	data []byte
+}
+
+func (p *rawPayload) MarshalJSON() ([]byte, error) { return p.data, nil }
+
+type Priority int
+
+func (Priority) EnumValues() []any { return []any{Priority(0), Priority(1)} }
+
+func (p Priority) MarshalText() ([]byte, error) { return []byte([]string{"low", "high"}[p]), nil }
+
+type ticket struct {
+	ID       ticketID            `json:"id"`
+	Payload  rawPayload          `json:"payload"`
+	Address  net.IP              `json:"address"`
+	Opened   time.Time           `json:"opened"`
+	Priority Priority            `json:"priority"`
+	Links    map[ticketID]string `json:"links"`
+	Counts   map[int]int         `json:"counts"`
+	Labels   map[string]string   `json:"labels"`
+}
+
+func TestMarshalerSchemas(t *testing.T) {
+	db := schemaBuilder{Schemas: &spec.Schemas{}, Config: Config{}}
+	db.addModelFrom(ticket{})
+	t.Log(asJSON(db.Schemas))
+
+	for name, want := range map[string]string{"restspec.ticketID": "string", "net.IP": "string", "restspec.rawPayload": ""} {
+		sm, ok := (*db.Schemas)[name]
+		if !ok {
+			t.Errorf("missing component %s", name)
+			continue
+		}
+		if got := strings.Join(sm.Value.Type.Slice(), ","); got != want || len(sm.Value.Properties) != 0 {
+			t.Errorf("%s: unexpected schema %v", name, asJSON(sm))
+		}
+	}
+	priority := (*db.Schemas)["restspec.Priority"].Value
+	if !priority.Type.Is("string") || !reflect.DeepEqual(priority.Enum, []interface{}{"low", "high"}) {
+		t.Errorf("unexpected priority %v", asJSON(priority))
+	}
+
+	sc := (*db.Schemas)["restspec.ticket"].Value
+	if got, want := sc.Properties["id"].Ref, "#/components/schemas/restspec.ticketID"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if opened := sc.Properties["opened"].Value; !opened.Type.Is("string") || opened.Format != "date-time" {
+		t.Errorf("unexpected opened %v", asJSON(opened))
+	}
+	if keys, ok := sc.Properties["links"].Value.Extensions[ExPropertyNames].(*spec.SchemaRef); !ok || keys.Ref != "#/components/schemas/restspec.ticketID" {
+		t.Errorf("unexpected links %v", asJSON(sc.Properties["links"]))
+	}
+	if keys, ok := sc.Properties["counts"].Value.Extensions[ExPropertyNames].(*spec.SchemaRef); !ok || !keys.Value.Type.Is("string") || keys.Value.Pattern == "" {
+		t.Errorf("unexpected counts %v", asJSON(sc.Properties["counts"]))
+	}
+	if _, ok := sc.Properties["labels"].Value.Extensions[ExPropertyNames]; ok {
+		t.Errorf("unexpected labels %v", asJSON(sc.Properties["labels"]))
+	}
+}
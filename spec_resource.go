@@ -24,30 +24,46 @@ func NewOpenAPIService(config Config) *restful.WebService {
 
 // BuildOpenAPIV3 returns a openapi object for all services' API endpoints.
 func BuildOpenAPIV3(config Config) *OpenAPI {
+	// the names of components do not depend on the order types are met in, so all types are collected first
+	names := collectNames(config, config.WebServices...)
+
 	// collect paths and model definitions to build Swagger object.
 	paths := &spec.Paths{}
 	components := &spec.Components{
-		Schemas: map[string]*spec.SchemaRef{},
+		Schemas:         map[string]*spec.SchemaRef{},
+		SecuritySchemes: spec.SecuritySchemes{},
+		Headers:         spec.Headers{},
+	}
+	for name, header := range config.Headers {
+		components.Headers[name] = buildHeaderFrom(header)
 	}
 
 	for _, each := range config.WebServices {
-		builds := buildPaths(each, config)
+		builds := buildPaths(each, config, names)
 		for path, item := range builds.Map() {
 			existingPathItem := paths.Find(path)
 			if existingPathItem != nil {
 				for _, r := range each.Routes() {
 					_, patterns := sanitizePath(r.Path)
-					*item = buildPathItem(each, r, *existingPathItem, patterns, config)
+					*item = buildPathItem(each, r, *existingPathItem, patterns, config, names)
 				}
 			}
 			paths.Set(path, item)
 		}
-		for name, schema := range buildSchemas(each, config) {
+		for name, schema := range buildSchemas(each, config, names) {
 			components.Schemas[name] = schema
 		}
+		for name, scheme := range buildSecuritySchemes(each) {
+			components.SecuritySchemes[name] = scheme
+		}
+	}
+	version := "3.0.1"
+	if config.Nullability.TypeNull {
+		// the type null is not known to OpenAPI 3.0
+		version = "3.1.0"
 	}
 	openapi := &OpenAPI{
-		OpenAPI:    "3.0.1",
+		OpenAPI:    version,
 		Components: components,
 		Info:       &spec.Info{},
 		Paths:      paths,
@@ -4,16 +4,55 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"os"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/emicklei/go-restful/v3"
 	spec "github.com/getkin/kin-openapi/openapi3"
+	"github.com/ggicci/httpin/core"
 )
 
 func dummy(i *restful.Request, o *restful.Response) {}
 
+// envWithoutDirectives is set in the environment of a test process run without the registrations below.
+const envWithoutDirectives = "RESTSPEC_TEST_WITHOUT_DIRECTIVES"
+
+// The inputs of the tests are checked by httpin like those bound by the integration package,
+// which registers the directives only documenting a parameter and the cookie directive.
+func init() {
+	if os.Getenv(envWithoutDirectives) != "" {
+		return
+	}
+	for _, name := range []string{
+		"description", "contentType", "pattern", "enum", "format", "example", "deprecated",
+		"min", "max", "minLength", "maxLength", "minItems", "maxItems", "cookie", "tenant",
+	} {
+		core.RegisterDirective(name, noopDirective{}, true)
+	}
+	core.RegisterNamedCoder[time.Time]("date", func(t *time.Time) (core.Stringable, error) {
+		return (*date)(t), nil
+	})
+}
+
+type noopDirective struct{}
+
+func (noopDirective) Decode(*core.DirectiveRuntime) error { return nil }
+func (noopDirective) Encode(*core.DirectiveRuntime) error { return nil }
+
+// date is a time.Time coded as a date, e.g. 2006-01-02.
+type date time.Time
+
+func (d *date) ToString() (string, error) { return time.Time(*d).Format(time.DateOnly), nil }
+
+func (d *date) FromString(s string) (err error) {
+	t, err := time.Parse(time.DateOnly, s)
+	*d = date(t)
+	return err
+}
+
 type Sample struct {
 	ID    string `swagger:"required"`
 	Root  Item   `json:"root" description:"root desc"`